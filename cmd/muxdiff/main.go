@@ -0,0 +1,175 @@
+// Copyright 2012 The Gorilla Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Command muxdiff reports the differences between two snapshots of the
+// route table of a mux.Router, as written by mux.WriteSnapshot, and exits
+// with status 1 if any of them is breaking:
+//
+//	muxdiff routes.golden.json routes.json
+//
+// The new snapshot can also be taken from a router constructor with the
+// signature func() *mux.Router, given as an import path and a function name.
+// Like muxgen, muxdiff then builds and runs a temporary program calling the
+// constructor, so it must be run from within the module of the constructor:
+//
+//	muxdiff -router example.com/app/routes.NewRouter routes.golden.json
+//
+// With -update, the golden file is overwritten with the new snapshot after
+// the differences are reported, and muxdiff exits with status 0.
+//
+// Flags:
+//
+//	-router  import path and name of the router constructor
+//	-update  overwrite the old snapshot with the new one
+//	-json    report the differences as JSON
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"text/template"
+
+	"github.com/gorilla/mux"
+	"github.com/gorilla/mux/internal/runner"
+)
+
+// errBreaking is returned by run when breaking changes were found.
+var errBreaking = errors.New("breaking changes found")
+
+func main() {
+	routerFlag := flag.String("router", "", "import path and name of the router constructor, e.g. example.com/app/routes.NewRouter")
+	updateFlag := flag.Bool("update", false, "overwrite the old snapshot with the new one")
+	jsonFlag := flag.Bool("json", false, "report the differences as JSON")
+	flag.Usage = func() {
+		fmt.Fprintln(flag.CommandLine.Output(), "usage: muxdiff [-update] [-json] old.json new.json")
+		fmt.Fprintln(flag.CommandLine.Output(), "       muxdiff -router importpath.Func [-update] [-json] old.json")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	err := run(os.Stdout, *routerFlag, *updateFlag, *jsonFlag, flag.Args())
+	if err == errBreaking {
+		os.Exit(1)
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "muxdiff:", err)
+		os.Exit(2)
+	}
+}
+
+func run(w io.Writer, router string, update, asJSON bool, args []string) error {
+	if (router == "" && len(args) != 2) || (router != "" && len(args) != 1) {
+		return errors.New("expected an old snapshot and either a new snapshot or -router")
+	}
+
+	old, err := readSnapshot(args[0])
+	if err != nil {
+		return err
+	}
+	var newJSON []byte
+	if router != "" {
+		newJSON, err = snapshotRouter(router)
+	} else {
+		newJSON, err = os.ReadFile(args[1])
+	}
+	if err != nil {
+		return err
+	}
+	current, err := mux.ReadSnapshot(bytes.NewReader(newJSON))
+	if err != nil {
+		return err
+	}
+
+	changes := mux.DiffRoutes(old, current)
+	if err := report(w, changes, asJSON); err != nil {
+		return err
+	}
+
+	if update {
+		var b bytes.Buffer
+		if err := mux.WriteSnapshot(&b, current); err != nil {
+			return err
+		}
+		return os.WriteFile(args[0], b.Bytes(), 0o644) // #nosec G306 -- checked-in golden file
+	}
+	for _, c := range changes {
+		if c.Breaking {
+			return errBreaking
+		}
+	}
+	return nil
+}
+
+// readSnapshot reads a snapshot file. A missing file is an empty snapshot, so
+// that -update can create it.
+func readSnapshot(name string) ([]mux.RouteSnapshot, error) {
+	f, err := os.Open(name)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return mux.ReadSnapshot(f)
+}
+
+func report(w io.Writer, changes []mux.RouteChange, asJSON bool) error {
+	if asJSON {
+		if changes == nil {
+			changes = []mux.RouteChange{}
+		}
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(changes)
+	}
+	for _, c := range changes {
+		if _, err := fmt.Fprintln(w, c); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// snapshotRouter returns the JSON snapshot of the router returned by the
+// given constructor.
+func snapshotRouter(router string) ([]byte, error) {
+	c, err := runner.ParseConstructor(router)
+	if err != nil {
+		return nil, err
+	}
+	out, err := runner.Run("muxdiff", programTemplate, map[string]string{
+		"RouterImport": c.ImportPath,
+		"RouterFunc":   c.Func,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("running snapshot program: %w", err)
+	}
+	return out, nil
+}
+
+var programTemplate = template.Must(template.New("").Parse(`// Code generated by muxdiff. DO NOT EDIT.
+
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/gorilla/mux"
+	routes {{printf "%q" .RouterImport}}
+)
+
+func main() {
+	if err := mux.WriteSnapshot(os.Stdout, routes.{{.RouterFunc}}().Snapshot()); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+`))
@@ -0,0 +1,101 @@
+// Copyright 2012 The Gorilla Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Command muxgen generates typed URL builders for the named routes of a
+// mux.Router. See package github.com/gorilla/mux/muxgen for the generated
+// code.
+//
+// The routes are obtained by calling a constructor with the signature
+// func() *mux.Router, given as an import path and a function name:
+//
+//	muxgen -router example.com/app/routes.NewRouter -o urls/urls.go
+//
+// The generated file doesn't import the package of the constructor: the URL
+// builders are created at run time from the router of the application.
+//
+// muxgen builds and runs a temporary program calling the constructor, so it
+// must be run from within the module of the constructor, e.g. through a
+// go:generate directive:
+//
+//	//go:generate go run github.com/gorilla/mux/cmd/muxgen -router example.com/app/routes.NewRouter -o urls.go
+//
+// Flags:
+//
+//	-router  import path and name of the router constructor (required)
+//	-o       output file (required)
+//	-package package name of the generated file (default: name of the output directory)
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"text/template"
+
+	"github.com/gorilla/mux/internal/runner"
+)
+
+func main() {
+	routerFlag := flag.String("router", "", "import path and name of the router constructor, e.g. example.com/app/routes.NewRouter")
+	outFlag := flag.String("o", "", "output file")
+	pkgFlag := flag.String("package", "", "package name of the generated file (default: name of the output directory)")
+	flag.Parse()
+
+	if err := run(*routerFlag, *outFlag, *pkgFlag); err != nil {
+		fmt.Fprintln(os.Stderr, "muxgen:", err)
+		os.Exit(1)
+	}
+}
+
+func run(router, out, pkg string) error {
+	if router == "" || out == "" {
+		return fmt.Errorf("both -router and -o are required")
+	}
+	c, err := runner.ParseConstructor(router)
+	if err != nil {
+		return err
+	}
+
+	out, err = filepath.Abs(out)
+	if err != nil {
+		return err
+	}
+	if pkg == "" {
+		pkg = filepath.Base(filepath.Dir(out))
+	}
+
+	src, err := runner.Run("muxgen", programTemplate, map[string]string{
+		"Package":      pkg,
+		"RouterImport": c.ImportPath,
+		"RouterFunc":   c.Func,
+	})
+	if err != nil {
+		return fmt.Errorf("running generator: %w", err)
+	}
+	return os.WriteFile(out, src, 0o644) // #nosec G306 -- generated source file
+}
+
+var programTemplate = template.Must(template.New("").Parse(`// Code generated by muxgen. DO NOT EDIT.
+
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/gorilla/mux/muxgen"
+	routes {{printf "%q" .RouterImport}}
+)
+
+func main() {
+	err := muxgen.Generate(os.Stdout, routes.{{.RouterFunc}}(), muxgen.Config{
+		Package: {{printf "%q" .Package}},
+	})
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+`))
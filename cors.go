@@ -0,0 +1,281 @@
+// Copyright 2012 The Gorilla Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package mux
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// CORSOptions configures the CORS middleware returned by Router.CORS.
+type CORSOptions struct {
+	// AllowedOrigins are the origins allowed to make cross-origin requests.
+	// An origin is either "*", allowing any origin, an exact origin such as
+	// "https://example.com", or an origin with a wildcard such as
+	// "https://*.example.com", allowing the subdomains of example.com, at any
+	// depth, but not example.com itself.
+	AllowedOrigins []string
+	// AllowOriginFunc, if set, allows the origins for which it returns true,
+	// in addition to AllowedOrigins.
+	AllowOriginFunc func(origin string, r *http.Request) bool
+	// AllowCredentials allows requests with credentials, such as cookies. It
+	// can't be combined with the "*" origin, which would let any website make
+	// authenticated requests.
+	AllowCredentials bool
+	// AllowedHeaders are the request headers allowed in cross-origin
+	// requests, besides CORS-safelisted ones. "*" allows any header.
+	AllowedHeaders []string
+	// ExposedHeaders are the response headers exposed to the scripts making
+	// cross-origin requests, besides CORS-safelisted ones.
+	ExposedHeaders []string
+	// MaxAge is how long the result of a preflight request can be cached. It
+	// is left to the browser if zero, and caching is disabled if negative.
+	MaxAge time.Duration
+	// AllowPrivateNetwork allows requests from public websites to servers on
+	// private networks, see https://wicg.github.io/private-network-access/.
+	AllowPrivateNetwork bool
+	// OptionsPassthrough passes preflight requests to the next handler once
+	// the CORS headers are set, instead of answering them with 204 No
+	// Content.
+	OptionsPassthrough bool
+}
+
+// CORSPolicy is the metadata key overriding the CORS options of the router
+// for a route, or for all the routes of a router if set with
+// Router.Metadata. Setting it to nil disables CORS for the route:
+//
+//	mux.CORSPolicy.Set(r.HandleFunc("/public", PublicHandler), &mux.CORSOptions{
+//		AllowedOrigins: []string{"*"},
+//	})
+var CORSPolicy = NewMetaKey[*CORSOptions]("CORSPolicy")
+
+// CORS returns a middleware handling Cross-Origin Resource Sharing for the
+// routes of the router, which is meant to be registered with UseAlways so that
+// it also handles unmatched requests:
+//
+//	r.UseAlways(r.CORS(mux.CORSOptions{
+//		AllowedOrigins: []string{"https://example.com", "https://*.example.com"},
+//		AllowedHeaders: []string{"Content-Type", "Authorization"},
+//		MaxAge:         time.Hour,
+//	}))
+//
+// Preflight requests are answered from the methods of all the routes of the
+// router and its subrouters matching the request regardless of its method,
+// so routes don't need an OPTIONS method matcher. The options of the first
+// of those routes matching the requested method can be overridden with the
+// CORSPolicy metadata key, as can the options of the route matching an actual
+// request.
+//
+// It panics if opts allow credentials from any origin. A CORSPolicy doing so
+// allows no origin.
+func (r *Router) CORS(opts CORSOptions) MiddlewareFunc {
+	if err := opts.validate(); err != nil {
+		panic(err)
+	}
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			origin := req.Header.Get("Origin")
+			if origin == "" {
+				next.ServeHTTP(w, req)
+				return
+			}
+			if req.Method == http.MethodOptions && req.Header.Get("Access-Control-Request-Method") != "" {
+				r.handlePreflight(w, req, next, &opts)
+				return
+			}
+
+			policy := &opts
+			if route := CurrentRoute(req); route != nil {
+				if p, ok := CORSPolicy.Get(route); ok {
+					policy = p
+				}
+			}
+			if policy != nil {
+				policy.setOriginHeaders(w, req, origin)
+				if w.Header().Get("Access-Control-Allow-Origin") != "" && len(policy.ExposedHeaders) > 0 {
+					w.Header().Set("Access-Control-Expose-Headers", strings.Join(policy.ExposedHeaders, ", "))
+				}
+			}
+			next.ServeHTTP(w, req)
+		})
+	}
+}
+
+// handlePreflight answers a preflight request.
+func (r *Router) handlePreflight(w http.ResponseWriter, req *http.Request, next http.Handler, opts *CORSOptions) {
+	reqMethod := req.Header.Get("Access-Control-Request-Method")
+	methods, route, ok := r.preflightMethods(req, reqMethod)
+	if !ok {
+		// No route matches the URL of the request.
+		next.ServeHTTP(w, req)
+		return
+	}
+	policy := opts
+	if route != nil {
+		if p, ok := CORSPolicy.Get(route); ok {
+			policy = p
+		}
+	}
+	if policy == nil {
+		next.ServeHTTP(w, req)
+		return
+	}
+
+	h := w.Header()
+	h.Add("Vary", "Access-Control-Request-Method")
+	h.Add("Vary", "Access-Control-Request-Headers")
+	if policy.AllowPrivateNetwork {
+		h.Add("Vary", "Access-Control-Request-Private-Network")
+	}
+	policy.setOriginHeaders(w, req, req.Header.Get("Origin"))
+	allowedHeaders, headersOK := policy.allowHeaders(req.Header.Values("Access-Control-Request-Headers"))
+	if h.Get("Access-Control-Allow-Origin") != "" && route != nil && headersOK {
+		h.Set("Access-Control-Allow-Methods", strings.Join(methods, ", "))
+		if len(allowedHeaders) > 0 {
+			h.Set("Access-Control-Allow-Headers", strings.Join(allowedHeaders, ", "))
+		}
+		if policy.MaxAge > 0 {
+			h.Set("Access-Control-Max-Age", strconv.Itoa(int(policy.MaxAge/time.Second)))
+		} else if policy.MaxAge < 0 {
+			h.Set("Access-Control-Max-Age", "0")
+		}
+		if policy.AllowPrivateNetwork && req.Header.Get("Access-Control-Request-Private-Network") == "true" {
+			h.Set("Access-Control-Allow-Private-Network", "true")
+		}
+	}
+
+	if policy.OptionsPassthrough {
+		next.ServeHTTP(w, req)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// preflightMethods returns the methods of the routes of the router and its
+// subrouters matching req regardless of its method, and the first of those
+// routes matching reqMethod, if any. It reports false if no route matches.
+func (r *Router) preflightMethods(req *http.Request, reqMethod string) ([]string, *Route, bool) {
+	var methods []string
+	var methodRoute *Route
+	found := false
+	_ = r.Walk(func(route *Route, _ *Router, _ []*Route) error {
+		if subrouterOf(route) != nil {
+			return nil
+		}
+		var match RouteMatch
+		if !route.Match(req, &match) && match.MatchErr != ErrMethodMismatch {
+			return nil
+		}
+		found = true
+		routeMethods, err := route.GetMethods()
+		if err != nil {
+			// The route matches any method.
+			routeMethods = []string{reqMethod}
+		}
+		for _, m := range routeMethods {
+			if m == reqMethod && methodRoute == nil {
+				methodRoute = route
+			}
+			if !containsFold(methods, m) {
+				methods = append(methods, m)
+			}
+		}
+		return nil
+	})
+	return methods, methodRoute, found
+}
+
+// validate returns an error if the options allow credentials from any
+// origin.
+func (o *CORSOptions) validate() error {
+	if o.AllowCredentials && containsFold(o.AllowedOrigins, "*") {
+		return errors.New(`mux: CORS credentials can't be allowed with the "*" origin`)
+	}
+	return nil
+}
+
+// setOriginHeaders sets the Access-Control-Allow-Origin and
+// Access-Control-Allow-Credentials headers if origin is allowed.
+func (o *CORSOptions) setOriginHeaders(w http.ResponseWriter, req *http.Request, origin string) {
+	h := w.Header()
+	allowed, anyOrigin := o.allowOrigin(origin, req)
+	if !anyOrigin || o.AllowCredentials {
+		h.Add("Vary", "Origin")
+	}
+	if !allowed {
+		return
+	}
+	if anyOrigin && !o.AllowCredentials {
+		h.Set("Access-Control-Allow-Origin", "*")
+	} else {
+		h.Set("Access-Control-Allow-Origin", origin)
+	}
+	if o.AllowCredentials {
+		h.Set("Access-Control-Allow-Credentials", "true")
+	}
+}
+
+// allowOrigin reports whether origin is allowed, and whether any origin is.
+func (o *CORSOptions) allowOrigin(origin string, req *http.Request) (allowed, anyOrigin bool) {
+	lower := strings.ToLower(origin)
+	for _, pattern := range o.AllowedOrigins {
+		pattern = strings.ToLower(pattern)
+		if pattern == "*" {
+			if o.AllowCredentials {
+				// Rejected by validate, but route policies are only
+				// checked here.
+				continue
+			}
+			return true, true
+		}
+		if prefix, suffix, ok := strings.Cut(pattern, "*"); ok {
+			if len(lower) > len(prefix)+len(suffix) &&
+				strings.HasPrefix(lower, prefix) && strings.HasSuffix(lower, suffix) {
+				allowed = true
+			}
+		} else if lower == pattern {
+			allowed = true
+		}
+	}
+	if !allowed && o.AllowOriginFunc != nil {
+		allowed = o.AllowOriginFunc(origin, req)
+	}
+	return allowed, false
+}
+
+// allowHeaders returns the requested headers, given as comma-separated
+// lists, and reports whether they are all allowed.
+func (o *CORSOptions) allowHeaders(values []string) ([]string, bool) {
+	var headers []string
+	for _, v := range values {
+		for _, name := range strings.Split(v, ",") {
+			if name = strings.TrimSpace(name); name != "" {
+				headers = append(headers, name)
+			}
+		}
+	}
+	if containsFold(o.AllowedHeaders, "*") {
+		return headers, true
+	}
+	for _, name := range headers {
+		if !containsFold(o.AllowedHeaders, name) {
+			return nil, false
+		}
+	}
+	return headers, true
+}
+
+// containsFold reports whether s contains v, ignoring case.
+func containsFold(s []string, v string) bool {
+	for _, e := range s {
+		if strings.EqualFold(e, v) {
+			return true
+		}
+	}
+	return false
+}
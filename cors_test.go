@@ -0,0 +1,269 @@
+package mux
+
+import (
+	"net/http"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestCORS(t *testing.T) {
+	r := NewRouter()
+	r.HandleFunc("/users", stringHandler("list")).Methods(http.MethodGet)
+	r.HandleFunc("/users", stringHandler("create")).Methods(http.MethodPost)
+	api := r.PathPrefix("/api").Subrouter()
+	api.HandleFunc("/items/{id}", stringHandler("item")).Methods(http.MethodGet, http.MethodPut)
+	api.HandleFunc("/any", stringHandler("any"))
+	public := r.HandleFunc("/public", stringHandler("public")).Methods(http.MethodGet)
+	CORSPolicy.Set(public, &CORSOptions{AllowedOrigins: []string{"*"}})
+	private := r.HandleFunc("/private", stringHandler("private"))
+	CORSPolicy.Set(private, nil)
+
+	r.UseAlways(r.CORS(CORSOptions{
+		AllowedOrigins:      []string{"https://example.com", "https://*.example.org"},
+		AllowOriginFunc:     func(origin string, _ *http.Request) bool { return origin == "https://trusted.test" },
+		AllowCredentials:    true,
+		AllowedHeaders:      []string{"Content-Type", "Authorization"},
+		ExposedHeaders:      []string{"X-Total-Count"},
+		MaxAge:              10 * time.Minute,
+		AllowPrivateNetwork: true,
+	}))
+
+	tests := []struct {
+		title       string
+		method      string
+		path        string
+		headers     map[string]string
+		wantCode    int
+		wantHeaders map[string]string
+		wantBody    string
+	}{
+		{
+			title:       "request without origin",
+			method:      http.MethodGet,
+			path:        "/users",
+			wantCode:    http.StatusOK,
+			wantHeaders: map[string]string{"Access-Control-Allow-Origin": ""},
+			wantBody:    "list",
+		},
+		{
+			title:    "actual request from an exact origin",
+			method:   http.MethodGet,
+			path:     "/users",
+			headers:  map[string]string{"Origin": "https://example.com"},
+			wantCode: http.StatusOK,
+			wantHeaders: map[string]string{
+				"Access-Control-Allow-Origin":      "https://example.com",
+				"Access-Control-Allow-Credentials": "true",
+				"Access-Control-Expose-Headers":    "X-Total-Count",
+				"Vary":                             "Origin",
+			},
+			wantBody: "list",
+		},
+		{
+			title:       "actual request from a wildcard origin",
+			method:      http.MethodGet,
+			path:        "/users",
+			headers:     map[string]string{"Origin": "https://a.b.example.org"},
+			wantCode:    http.StatusOK,
+			wantHeaders: map[string]string{"Access-Control-Allow-Origin": "https://a.b.example.org"},
+			wantBody:    "list",
+		},
+		{
+			title:       "wildcard doesn't match the domain itself",
+			method:      http.MethodGet,
+			path:        "/users",
+			headers:     map[string]string{"Origin": "https://example.org"},
+			wantCode:    http.StatusOK,
+			wantHeaders: map[string]string{"Access-Control-Allow-Origin": ""},
+			wantBody:    "list",
+		},
+		{
+			title:       "origin allowed by predicate",
+			method:      http.MethodGet,
+			path:        "/users",
+			headers:     map[string]string{"Origin": "https://trusted.test"},
+			wantCode:    http.StatusOK,
+			wantHeaders: map[string]string{"Access-Control-Allow-Origin": "https://trusted.test"},
+			wantBody:    "list",
+		},
+		{
+			title:       "not found responses get CORS headers",
+			method:      http.MethodGet,
+			path:        "/missing",
+			headers:     map[string]string{"Origin": "https://example.com"},
+			wantCode:    http.StatusNotFound,
+			wantHeaders: map[string]string{"Access-Control-Allow-Origin": "https://example.com"},
+			wantBody:    "404 page not found\n",
+		},
+		{
+			title:   "preflight",
+			method:  http.MethodOptions,
+			path:    "/users",
+			headers: map[string]string{"Origin": "https://example.com", "Access-Control-Request-Method": "POST", "Access-Control-Request-Headers": "content-type, authorization"},
+			wantHeaders: map[string]string{
+				"Access-Control-Allow-Origin":      "https://example.com",
+				"Access-Control-Allow-Credentials": "true",
+				"Access-Control-Allow-Methods":     "GET, POST",
+				"Access-Control-Allow-Headers":     "content-type, authorization",
+				"Access-Control-Max-Age":           "600",
+				"Access-Control-Expose-Headers":    "",
+			},
+			wantCode: http.StatusNoContent,
+		},
+		{
+			title:       "preflight of a subrouter route",
+			method:      http.MethodOptions,
+			path:        "/api/items/1",
+			headers:     map[string]string{"Origin": "https://example.com", "Access-Control-Request-Method": "PUT"},
+			wantHeaders: map[string]string{"Access-Control-Allow-Methods": "GET, PUT"},
+			wantCode:    http.StatusNoContent,
+		},
+		{
+			title:       "preflight of a route without methods",
+			method:      http.MethodOptions,
+			path:        "/api/any",
+			headers:     map[string]string{"Origin": "https://example.com", "Access-Control-Request-Method": "DELETE"},
+			wantHeaders: map[string]string{"Access-Control-Allow-Methods": "DELETE"},
+			wantCode:    http.StatusNoContent,
+		},
+		{
+			title:       "preflight of a method not allowed",
+			method:      http.MethodOptions,
+			path:        "/users",
+			headers:     map[string]string{"Origin": "https://example.com", "Access-Control-Request-Method": "DELETE"},
+			wantHeaders: map[string]string{"Access-Control-Allow-Methods": ""},
+			wantCode:    http.StatusNoContent,
+		},
+		{
+			title:       "preflight with a header not allowed",
+			method:      http.MethodOptions,
+			path:        "/users",
+			headers:     map[string]string{"Origin": "https://example.com", "Access-Control-Request-Method": "GET", "Access-Control-Request-Headers": "X-Custom"},
+			wantHeaders: map[string]string{"Access-Control-Allow-Methods": "", "Access-Control-Allow-Headers": ""},
+			wantCode:    http.StatusNoContent,
+		},
+		{
+			title:       "preflight from an origin not allowed",
+			method:      http.MethodOptions,
+			path:        "/users",
+			headers:     map[string]string{"Origin": "https://evil.test", "Access-Control-Request-Method": "GET"},
+			wantHeaders: map[string]string{"Access-Control-Allow-Origin": "", "Access-Control-Allow-Methods": ""},
+			wantCode:    http.StatusNoContent,
+		},
+		{
+			title:       "preflight for a private network",
+			method:      http.MethodOptions,
+			path:        "/users",
+			headers:     map[string]string{"Origin": "https://example.com", "Access-Control-Request-Method": "GET", "Access-Control-Request-Private-Network": "true"},
+			wantHeaders: map[string]string{"Access-Control-Allow-Private-Network": "true"},
+			wantCode:    http.StatusNoContent,
+		},
+		{
+			title:       "preflight of an unknown path",
+			method:      http.MethodOptions,
+			path:        "/missing",
+			headers:     map[string]string{"Origin": "https://example.com", "Access-Control-Request-Method": "GET"},
+			wantHeaders: map[string]string{"Access-Control-Allow-Methods": ""},
+			wantCode:    http.StatusNotFound,
+			wantBody:    "404 page not found\n",
+		},
+		{
+			title:       "route policy",
+			method:      http.MethodGet,
+			path:        "/public",
+			headers:     map[string]string{"Origin": "https://evil.test"},
+			wantHeaders: map[string]string{"Access-Control-Allow-Origin": "*", "Access-Control-Allow-Credentials": ""},
+			wantCode:    http.StatusOK,
+			wantBody:    "public",
+		},
+		{
+			title:       "route policy in preflight",
+			method:      http.MethodOptions,
+			path:        "/public",
+			headers:     map[string]string{"Origin": "https://evil.test", "Access-Control-Request-Method": "GET"},
+			wantHeaders: map[string]string{"Access-Control-Allow-Origin": "*", "Access-Control-Allow-Methods": "GET"},
+			wantCode:    http.StatusNoContent,
+		},
+		{
+			title:       "CORS disabled for a route",
+			method:      http.MethodGet,
+			path:        "/private",
+			headers:     map[string]string{"Origin": "https://example.com"},
+			wantHeaders: map[string]string{"Access-Control-Allow-Origin": ""},
+			wantCode:    http.StatusOK,
+			wantBody:    "private",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.title, func(t *testing.T) {
+			req := newRequest(tc.method, tc.path)
+			for k, v := range tc.headers {
+				req.Header.Set(k, v)
+			}
+			rw := NewRecorder()
+			r.ServeHTTP(rw, req)
+			if rw.Code != tc.wantCode {
+				t.Errorf("Expected status %d, got %d", tc.wantCode, rw.Code)
+			}
+			for k, v := range tc.wantHeaders {
+				if got := rw.Header().Get(k); got != v {
+					t.Errorf("Expected %s %q, got %q", k, v, got)
+				}
+			}
+			if rw.Body.String() != tc.wantBody {
+				t.Errorf("Expected body %q, got %q", tc.wantBody, rw.Body.String())
+			}
+		})
+	}
+}
+
+func TestCORSVary(t *testing.T) {
+	r := NewRouter()
+	r.HandleFunc("/", stringHandler("home"))
+	r.UseAlways(r.CORS(CORSOptions{AllowedOrigins: []string{"*"}}))
+
+	req := newRequest(http.MethodOptions, "/")
+	req.Header.Set("Origin", "https://example.com")
+	req.Header.Set("Access-Control-Request-Method", "GET")
+	rw := NewRecorder()
+	r.ServeHTTP(rw, req)
+
+	if got := rw.Header().Get("Access-Control-Allow-Origin"); got != "*" {
+		t.Errorf("Expected %q, got %q", "*", got)
+	}
+	expected := []string{"Access-Control-Request-Method", "Access-Control-Request-Headers"}
+	if got := rw.Header().Values("Vary"); !reflect.DeepEqual(got, expected) {
+		t.Errorf("Expected Vary %v, got %v", expected, got)
+	}
+}
+
+func TestCORSCredentialsWithAnyOrigin(t *testing.T) {
+	func() {
+		defer func() {
+			if recover() == nil {
+				t.Errorf("Expected a panic for credentials allowed from any origin")
+			}
+		}()
+		NewRouter().CORS(CORSOptions{AllowedOrigins: []string{"*"}, AllowCredentials: true})
+	}()
+
+	// Route policies can't be checked when they are set, so they allow no
+	// origin instead.
+	r := NewRouter()
+	route := r.HandleFunc("/", stringHandler("home"))
+	CORSPolicy.Set(route, &CORSOptions{AllowedOrigins: []string{"*"}, AllowCredentials: true})
+	r.UseAlways(r.CORS(CORSOptions{}))
+
+	req := newRequest(http.MethodGet, "/")
+	req.Header.Set("Origin", "https://evil.example")
+	rw := NewRecorder()
+	r.ServeHTTP(rw, req)
+	if got := rw.Header().Get("Access-Control-Allow-Origin"); got != "" {
+		t.Errorf("Expected no Access-Control-Allow-Origin, got %q", got)
+	}
+	if got := rw.Header().Get("Access-Control-Allow-Credentials"); got != "" {
+		t.Errorf("Expected no Access-Control-Allow-Credentials, got %q", got)
+	}
+}
@@ -0,0 +1,177 @@
+// Copyright 2012 The Gorilla Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package mux
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// WriteDOT writes the tree of the router and its subrouters as a Graphviz DOT
+// graph, e.g. to be rendered with:
+//
+//	dot -Tsvg routes.dot > routes.svg
+//
+// Each router is a node, and each subrouter is drawn as a cluster together
+// with its routes. Routes are labelled with their methods, templates and
+// name, and are linked to their router by edges numbered in match order.
+// The routes registered by a group are drawn in a dotted cluster. Routers and
+// groups are labelled with the number of their middlewares.
+//
+// A group created with With or Group writes the router owning it with only
+// the routes it registered.
+func (r *Router) WriteDOT(w io.Writer) error {
+	d := &dotWriter{}
+	d.WriteString("digraph mux {\n")
+	d.WriteString("\tcompound=true;\n")
+	d.WriteString("\trankdir=LR;\n")
+	d.WriteString("\tnode [shape=box, fontname=\"monospace\"];\n")
+	d.router(r.owner(), r, 1)
+	d.WriteString("}\n")
+	_, err := w.Write(d.Bytes())
+	return err
+}
+
+// dotWriter writes a DOT graph, numbering its nodes.
+type dotWriter struct {
+	bytes.Buffer
+	routers int
+	routes  int
+	groups  int
+}
+
+// router writes the node of router and those of its routes registered by
+// scope, see Router.match, and returns the ID of the router node.
+func (d *dotWriter) router(router, scope *Router, depth int) string {
+	indent := strings.Repeat("\t", depth)
+	id := fmt.Sprintf("router%d", d.routers)
+	d.routers++
+	label := "router"
+	if n := len(router.middlewares); n > 0 {
+		label += fmt.Sprintf("\nmiddlewares: %d", n)
+	}
+	fmt.Fprintf(d, "%s%s [shape=ellipse, label=%s];\n", indent, id, dotQuote(label))
+
+	// IDs of the routes registered by each group, and groups created from
+	// each router or group, in order.
+	groupRoutes := make(map[*Router][]string)
+	subgroups := make(map[*Router][]*Router)
+	seen := make(map[*Router]bool)
+	n := 0
+	for _, route := range router.routes {
+		if !scope.registered(route) {
+			continue
+		}
+		n++
+		routeID := fmt.Sprintf("route%d", d.routes)
+		d.routes++
+		fmt.Fprintf(d, "%s%s [label=%s];\n", indent, routeID, dotQuote(dotRouteLabel(route)))
+		fmt.Fprintf(d, "%s%s -> %s [label=\"%d\"];\n", indent, id, routeID, n)
+		if g := route.group; g != nil {
+			for ; g.parent != nil && !seen[g]; g = g.parent {
+				seen[g] = true
+				subgroups[g.parent] = append(subgroups[g.parent], g)
+			}
+			groupRoutes[route.group] = append(groupRoutes[route.group], routeID)
+		}
+
+		sr := subrouterOf(route)
+		if sr == nil {
+			continue
+		}
+		cluster := fmt.Sprintf("cluster_%d", d.routers)
+		fmt.Fprintf(d, "%ssubgraph %s {\n", indent, cluster)
+		fmt.Fprintf(d, "%s\tstyle=dashed;\n", indent)
+		fmt.Fprintf(d, "%s\tlabel=%s;\n", indent, dotQuote(describeRoute(route)))
+		subID := d.router(sr, sr, depth+1)
+		fmt.Fprintf(d, "%s}\n", indent)
+		fmt.Fprintf(d, "%s%s -> %s [lhead=%s];\n", indent, routeID, subID, cluster)
+	}
+	for _, g := range subgroups[router] {
+		d.group(g, groupRoutes, subgroups, depth)
+	}
+	return id
+}
+
+// group writes the cluster of group, holding the nodes of the routes it
+// registered and the clusters of its groups.
+func (d *dotWriter) group(group *Router, routes map[*Router][]string, subgroups map[*Router][]*Router, depth int) {
+	indent := strings.Repeat("\t", depth)
+	fmt.Fprintf(d, "%ssubgraph cluster_group%d {\n", indent, d.groups)
+	d.groups++
+	fmt.Fprintf(d, "%s\tstyle=dotted;\n", indent)
+	label := "group"
+	if n := len(group.middlewares); n > 0 {
+		label += fmt.Sprintf("\nmiddlewares: %d", n)
+	}
+	fmt.Fprintf(d, "%s\tlabel=%s;\n", indent, dotQuote(label))
+	for _, id := range routes[group] {
+		fmt.Fprintf(d, "%s\t%s;\n", indent, id)
+	}
+	for _, g := range subgroups[group] {
+		d.group(g, routes, subgroups, depth+1)
+	}
+	fmt.Fprintf(d, "%s}\n", indent)
+}
+
+// dotRouteLabel returns the label of the node of a route.
+func dotRouteLabel(route *Route) string {
+	var lines []string
+	var conds []string
+	for _, m := range route.matchers {
+		switch m := m.(type) {
+		case methodMatcher:
+			conds = append(conds, strings.Join(m, ","))
+		case schemeMatcher:
+			conds = append(conds, strings.Join(m, ",")+"://")
+		}
+	}
+	if len(conds) > 0 {
+		lines = append(lines, strings.Join(conds, " "))
+	}
+	var tpl string
+	if route.regexp.host != nil {
+		tpl = route.regexp.host.template
+	}
+	if route.regexp.path != nil {
+		tpl += route.regexp.path.template
+		if route.regexp.path.regexpType == regexpTypePrefix {
+			tpl += "*"
+		}
+	}
+	for i, q := range route.regexp.queries {
+		sep := "&"
+		if i == 0 {
+			sep = "?"
+		}
+		tpl += sep + q.template
+	}
+	if tpl != "" {
+		lines = append(lines, tpl)
+	}
+	if route.name != "" {
+		lines = append(lines, "name: "+route.name)
+	}
+	if n := len(route.middlewares); n > 0 {
+		lines = append(lines, fmt.Sprintf("middlewares: %d", n))
+	}
+	if route.err != nil {
+		lines = append(lines, "error: "+route.err.Error())
+	}
+	if len(lines) == 0 {
+		return "(any)"
+	}
+	return strings.Join(lines, "\n")
+}
+
+// dotQuote returns s as a quoted DOT string, with newlines as line breaks.
+func dotQuote(s string) string {
+	s = strings.ReplaceAll(s, `\`, `\\`)
+	s = strings.ReplaceAll(s, `"`, `\"`)
+	s = strings.ReplaceAll(s, "\n", `\n`)
+	return `"` + s + `"`
+}
@@ -0,0 +1,84 @@
+package mux
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestWriteDOT(t *testing.T) {
+	r := NewRouter()
+	r.Use(func(h http.Handler) http.Handler { return h })
+	r.HandleFunc("/", nil).Methods("GET").Name("home")
+	api := r.Host("api.example.com").PathPrefix("/v1").Subrouter()
+	api.HandleFunc("/users/{id:[0-9]+}", nil).Methods("GET", "PUT").Queries("q", `{q}`).Name("user")
+
+	var b strings.Builder
+	if err := r.WriteDOT(&b); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	out := b.String()
+
+	for _, want := range []string{
+		"digraph mux {",
+		`router0 [shape=ellipse, label="router\nmiddlewares: 1"];`,
+		`route0 [label="GET\n/\nname: home"];`,
+		`router0 -> route0 [label="1"];`,
+		`router0 -> route1 [label="2"];`,
+		"subgraph cluster_1 {",
+		`label="api.example.com/v1";`,
+		`route2 [label="GET,PUT\napi.example.com/v1/users/{id:[0-9]+}?q={q}\nname: user"];`,
+		`router1 -> route2 [label="1"];`,
+		"route1 -> router1 [lhead=cluster_1];",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("Expected output to contain %q:\n%s", want, out)
+		}
+	}
+	if strings.Count(out, "{") != strings.Count(out, "}") {
+		t.Errorf("Unbalanced braces:\n%s", out)
+	}
+}
+
+func TestWriteDOTGroups(t *testing.T) {
+	mw := func(h http.Handler) http.Handler { return h }
+	r := NewRouter()
+	r.HandleFunc("/", nil)
+	auth := r.With(mw)
+	admin := auth.With(mw, mw)
+	admin.HandleFunc("/admin", nil)
+	auth.HandleFunc("/account", nil)
+
+	var b strings.Builder
+	if err := r.WriteDOT(&b); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	out := b.String()
+	for _, want := range []string{
+		"subgraph cluster_group0 {\n\t\tstyle=dotted;\n\t\tlabel=\"group\\nmiddlewares: 1\";\n\t\troute2;\n" +
+			"\t\tsubgraph cluster_group1 {\n\t\t\tstyle=dotted;\n\t\t\tlabel=\"group\\nmiddlewares: 2\";\n\t\t\troute1;\n\t\t}\n\t}\n",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("Expected output to contain %q:\n%s", want, out)
+		}
+	}
+
+	// A group writes only the routes it registered.
+	b.Reset()
+	if err := admin.WriteDOT(&b); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	out = b.String()
+	if !strings.Contains(out, `route0 [label="/admin"];`) || strings.Contains(out, "route1") {
+		t.Errorf("Expected only the routes of the group:\n%s", out)
+	}
+	if strings.Count(out, "{") != strings.Count(out, "}") {
+		t.Errorf("Unbalanced braces:\n%s", out)
+	}
+}
+
+func Test_dotQuote(t *testing.T) {
+	if got, expected := dotQuote("a\"b\\c\nd"), `"a\"b\\c\nd"`; got != expected {
+		t.Errorf("Expected %s, got %s", expected, got)
+	}
+}
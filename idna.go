@@ -0,0 +1,148 @@
+// Copyright 2012 The Gorilla Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package mux
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+// hostToASCII converts the labels of a host that contain non-ASCII
+// characters to their punycode form, as defined by IDNA (RFC 5891).
+//
+// Only the lowercase mapping is applied to those labels: full UTS #46
+// processing is out of the scope of this package. ASCII labels, including
+// the port, are left untouched.
+func hostToASCII(host string) (string, error) {
+	if isASCII(host) {
+		return host, nil
+	}
+	// IPv6 addresses are ASCII, so a colon separates the port.
+	var port string
+	if i := strings.LastIndexByte(host, ':'); i != -1 {
+		host, port = host[:i], host[i:]
+	}
+	labels := strings.Split(host, ".")
+	for i, label := range labels {
+		if isASCII(label) {
+			continue
+		}
+		if !utf8.ValidString(label) {
+			return "", fmt.Errorf("mux: invalid UTF-8 in host %q", host)
+		}
+		encoded, err := punycodeEncode(strings.ToLower(label))
+		if err != nil {
+			return "", fmt.Errorf("mux: cannot convert host %q to ASCII: %w", host, err)
+		}
+		labels[i] = "xn--" + encoded
+	}
+	return strings.Join(labels, ".") + port, nil
+}
+
+func isASCII(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] >= utf8.RuneSelf {
+			return false
+		}
+	}
+	return true
+}
+
+// Bootstring parameters for punycode, see section 5 of RFC 3492.
+const (
+	punyBase        = 36
+	punyTMin        = 1
+	punyTMax        = 26
+	punySkew        = 38
+	punyDamp        = 700
+	punyInitialBias = 72
+	punyInitialN    = 128
+)
+
+// punycodeEncode encodes s as described in section 6.3 of RFC 3492.
+func punycodeEncode(s string) (string, error) {
+	runes := []rune(s)
+	var b strings.Builder
+	for _, r := range runes {
+		if r < utf8.RuneSelf {
+			b.WriteByte(byte(r))
+		}
+	}
+	basic := b.Len()
+	handled := basic
+	if basic > 0 {
+		b.WriteByte('-')
+	}
+	n, delta, bias := int32(punyInitialN), int32(0), int32(punyInitialBias)
+	for handled < len(runes) {
+		m := int32(utf8.MaxRune)
+		for _, r := range runes {
+			if r >= n && r < m {
+				m = r
+			}
+		}
+		if (m - n) > (1<<31-1-delta)/int32(handled+1) {
+			return "", fmt.Errorf("punycode overflow")
+		}
+		delta += (m - n) * int32(handled+1)
+		n = m
+		for _, r := range runes {
+			if r < n {
+				delta++
+				if delta < 0 {
+					return "", fmt.Errorf("punycode overflow")
+				}
+			}
+			if r != n {
+				continue
+			}
+			q := delta
+			for k := int32(punyBase); ; k += punyBase {
+				t := k - bias
+				if t < punyTMin {
+					t = punyTMin
+				} else if t > punyTMax {
+					t = punyTMax
+				}
+				if q < t {
+					break
+				}
+				b.WriteByte(punyDigit(t + (q-t)%(punyBase-t)))
+				q = (q - t) / (punyBase - t)
+			}
+			b.WriteByte(punyDigit(q))
+			bias = punyAdapt(delta, int32(handled+1), handled == basic)
+			delta = 0
+			handled++
+		}
+		delta++
+		n++
+	}
+	return b.String(), nil
+}
+
+// punyAdapt is the bias adaptation function of section 6.1 of RFC 3492.
+func punyAdapt(delta, numPoints int32, first bool) int32 {
+	if first {
+		delta /= punyDamp
+	} else {
+		delta /= 2
+	}
+	delta += delta / numPoints
+	k := int32(0)
+	for delta > ((punyBase-punyTMin)*punyTMax)/2 {
+		delta /= punyBase - punyTMin
+		k += punyBase
+	}
+	return k + (punyBase-punyTMin+1)*delta/(delta+punySkew)
+}
+
+func punyDigit(d int32) byte {
+	if d < 26 {
+		return byte('a' + d)
+	}
+	return byte('0' + d - 26)
+}
@@ -0,0 +1,28 @@
+package mux
+
+import "testing"
+
+func Test_hostToASCII(t *testing.T) {
+	tests := []struct {
+		in, out string
+	}{
+		{"example.com", "example.com"},
+		{"bücher.example.com", "xn--bcher-kva.example.com"},
+		{"MÜNCHEN.de:8080", "xn--mnchen-3ya.de:8080"},
+		{"bücher:8080", "xn--bcher-kva:8080"},
+		{"a.example.рф:8080", "a.example.xn--p1ai:8080"},
+		{"例え.テスト", "xn--r8jz45g.xn--zckzah"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.in, func(t *testing.T) {
+			got, err := hostToASCII(tc.in)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if got != tc.out {
+				t.Errorf("hostToASCII(%q) = %q, want %q", tc.in, got, tc.out)
+			}
+		})
+	}
+}
@@ -0,0 +1,128 @@
+// Copyright 2012 The Gorilla Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package routetpl parses the host, path and query templates returned by the
+// getters of mux.Route, for the tooling built on top of the router.
+package routetpl
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Default patterns of the variables which don't define one, per template
+// type.
+const (
+	DefaultHostPattern  = "[^.]+"
+	DefaultPathPattern  = "[^/]+"
+	DefaultQueryPattern = ".*"
+)
+
+// Var is a variable of a template.
+type Var struct {
+	// Name is the name of the variable.
+	Name string
+	// Pattern is the regular expression the variable must match, without
+	// anchors.
+	Pattern string
+}
+
+// Parse returns the variables of tpl, in order. Variables which don't
+// define a pattern are given defaultPattern.
+func Parse(tpl, defaultPattern string) ([]Var, error) {
+	idxs, err := braceIndices(tpl)
+	if err != nil {
+		return nil, err
+	}
+	vars := make([]Var, 0, len(idxs)/2)
+	for i := 0; i < len(idxs); i += 2 {
+		param := tpl[idxs[i]+1 : idxs[i+1]-1]
+		name, pattern, ok := strings.Cut(param, ":")
+		if !ok {
+			pattern = defaultPattern
+		}
+		if name == "" || pattern == "" {
+			return nil, fmt.Errorf("routetpl: missing name or pattern in %q", tpl[idxs[i]:idxs[i+1]])
+		}
+		vars = append(vars, Var{Name: name, Pattern: pattern})
+	}
+	return vars, nil
+}
+
+// Strip returns tpl with the patterns of its variables removed, e.g.
+// "/articles/{id}" for "/articles/{id:[0-9]+}".
+func Strip(tpl string) (string, error) {
+	idxs, err := braceIndices(tpl)
+	if err != nil {
+		return "", err
+	}
+	var b strings.Builder
+	end := 0
+	for i := 0; i < len(idxs); i += 2 {
+		b.WriteString(tpl[end:idxs[i]])
+		name, _, _ := strings.Cut(tpl[idxs[i]+1:idxs[i+1]-1], ":")
+		b.WriteString("{" + name + "}")
+		end = idxs[i+1]
+	}
+	b.WriteString(tpl[end:])
+	return b.String(), nil
+}
+
+// Regexp returns the anchored regular expression matching tpl, with the
+// text around variables quoted. Variables which don't define a pattern are
+// given defaultPattern.
+func Regexp(tpl, defaultPattern string) (string, error) {
+	idxs, err := braceIndices(tpl)
+	if err != nil {
+		return "", err
+	}
+	var b strings.Builder
+	b.WriteByte('^')
+	end := 0
+	for i := 0; i < len(idxs); i += 2 {
+		b.WriteString(regexp.QuoteMeta(tpl[end:idxs[i]]))
+		_, pattern, ok := strings.Cut(tpl[idxs[i]+1:idxs[i+1]-1], ":")
+		if !ok {
+			pattern = defaultPattern
+		}
+		b.WriteString("(?:" + pattern + ")")
+		end = idxs[i+1]
+	}
+	b.WriteString(regexp.QuoteMeta(tpl[end:]))
+	b.WriteByte('$')
+	return b.String(), nil
+}
+
+// SplitQuery splits a query template as returned by
+// mux.Route.GetQueriesTemplates into its key and value templates.
+func SplitQuery(tpl string) (key, value string) {
+	key, value, _ = strings.Cut(tpl, "=")
+	return key, value
+}
+
+// braceIndices returns the first level curly brace indices from a string.
+// It returns an error in case of unbalanced braces.
+func braceIndices(s string) ([]int, error) {
+	var level, idx int
+	var idxs []int
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '{':
+			if level++; level == 1 {
+				idx = i
+			}
+		case '}':
+			if level--; level == 0 {
+				idxs = append(idxs, idx, i+1)
+			} else if level < 0 {
+				return nil, fmt.Errorf("routetpl: unbalanced braces in %q", s)
+			}
+		}
+	}
+	if level != 0 {
+		return nil, fmt.Errorf("routetpl: unbalanced braces in %q", s)
+	}
+	return idxs, nil
+}
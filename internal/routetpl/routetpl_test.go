@@ -0,0 +1,53 @@
+package routetpl
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		tpl  string
+		vars []Var
+	}{
+		{"/articles", []Var{}},
+		{"/articles/{category}/{id:[0-9]+}", []Var{{"category", DefaultPathPattern}, {"id", "[0-9]+"}}},
+		{"/{v:[a-z]{3}}", []Var{{"v", "[a-z]{3}"}}},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.tpl, func(t *testing.T) {
+			vars, err := Parse(tc.tpl, DefaultPathPattern)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(vars, tc.vars) {
+				t.Errorf("Expected %v, got %v", tc.vars, vars)
+			}
+		})
+	}
+
+	if _, err := Parse("/{id", DefaultPathPattern); err == nil {
+		t.Error("Expected an error for unbalanced braces")
+	}
+}
+
+func TestStrip(t *testing.T) {
+	got, err := Strip("/articles/{category}/{id:[0-9]{1,3}}/")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if expected := "/articles/{category}/{id}/"; got != expected {
+		t.Errorf("Expected %q, got %q", expected, got)
+	}
+}
+
+func TestRegexp(t *testing.T) {
+	got, err := Regexp("{a}.{b:[0-9]+}", DefaultQueryPattern)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if expected := `^(?:.*)\.(?:[0-9]+)$`; got != expected {
+		t.Errorf("Expected %q, got %q", expected, got)
+	}
+}
@@ -0,0 +1,72 @@
+// Copyright 2012 The Gorilla Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package runner builds and runs temporary programs calling a router
+// constructor, for the commands which need the routes of an application.
+package runner
+
+import (
+	"bytes"
+	"fmt"
+	"go/token"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"text/template"
+)
+
+// Constructor is a function with the signature func() *mux.Router.
+type Constructor struct {
+	// ImportPath is the import path of the package of the function.
+	ImportPath string
+	// Func is the name of the function.
+	Func string
+}
+
+// ParseConstructor parses a constructor given as an import path and a
+// function name, e.g. "example.com/app/routes.NewRouter".
+func ParseConstructor(s string) (Constructor, error) {
+	i := strings.LastIndex(s, ".")
+	if i <= 0 || i < strings.LastIndex(s, "/") {
+		return Constructor{}, fmt.Errorf("invalid -router %q, expected importpath.Func", s)
+	}
+	c := Constructor{ImportPath: s[:i], Func: s[i+1:]}
+	if !token.IsExported(c.Func) {
+		return Constructor{}, fmt.Errorf("invalid -router %q, %s is not exported", s, c.Func)
+	}
+	return c, nil
+}
+
+// Run writes the program executed from tmpl with data in a temporary
+// directory of the current module, runs it, and returns its standard output.
+// The standard error of the program is the one of the process.
+//
+// The program is written within the current module, so that the import of
+// the constructor resolves. The directory is named after name, prefixed with
+// an underscore which keeps it out of ./... patterns if it is left behind.
+func Run(name string, tmpl *template.Template, data any) ([]byte, error) {
+	dir, err := os.MkdirTemp(".", "_"+name)
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(dir)
+
+	var src bytes.Buffer
+	if err := tmpl.Execute(&src, data); err != nil {
+		return nil, err
+	}
+	if err := os.WriteFile(filepath.Join(dir, "main.go"), src.Bytes(), 0o600); err != nil {
+		return nil, err
+	}
+
+	var stdout bytes.Buffer
+	cmd := exec.Command("go", "run", "./"+filepath.ToSlash(dir))
+	cmd.Stdout = &stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		return nil, err
+	}
+	return stdout.Bytes(), nil
+}
@@ -0,0 +1,53 @@
+package runner
+
+import (
+	"path/filepath"
+	"testing"
+	"text/template"
+)
+
+func TestParseConstructor(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    Constructor
+		wantErr bool
+	}{
+		{"example.com/app/routes.NewRouter", Constructor{"example.com/app/routes", "NewRouter"}, false},
+		{"example.com/app.v2/routes.NewRouter", Constructor{"example.com/app.v2/routes", "NewRouter"}, false},
+		{"routes.NewRouter", Constructor{"routes", "NewRouter"}, false},
+		{"example.com/app/routes", Constructor{}, true},
+		{"example.com/app.v2/routes", Constructor{}, true},
+		{".NewRouter", Constructor{}, true},
+		{"example.com/app/routes.newRouter", Constructor{}, true},
+	}
+	for _, tc := range tests {
+		got, err := ParseConstructor(tc.in)
+		if (err != nil) != tc.wantErr {
+			t.Errorf("%s: Expected error %v, got %v", tc.in, tc.wantErr, err)
+		}
+		if got != tc.want {
+			t.Errorf("%s: Expected %+v, got %+v", tc.in, tc.want, got)
+		}
+	}
+}
+
+func TestRun(t *testing.T) {
+	tmpl := template.Must(template.New("").Parse(`package main
+
+import "fmt"
+
+func main() {
+	fmt.Print({{printf "%q" .}})
+}
+`))
+	out, err := Run("runnertest", tmpl, "hello")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if string(out) != "hello" {
+		t.Errorf("Expected %q, got %q", "hello", out)
+	}
+	if dirs, _ := filepath.Glob("_runnertest*"); len(dirs) != 0 {
+		t.Errorf("Expected the program to be removed, got %v", dirs)
+	}
+}
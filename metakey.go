@@ -0,0 +1,71 @@
+// Copyright 2012 The Gorilla Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package mux
+
+import "net/http"
+
+// MetaKey is a typed key of route metadata, holding values of type T. Keys
+// are compared by identity, so they are usually declared once as package
+// variables:
+//
+//	var RequiredScope = mux.NewMetaKey[string]("RequiredScope")
+//
+//	RequiredScope.Set(r.HandleFunc("/admin", handler), "admin")
+//
+// and then read by middleware with:
+//
+//	if scope, ok := RequiredScope.FromRequest(req); ok {
+//		...
+//	}
+type MetaKey[T any] struct {
+	name string
+}
+
+// NewMetaKey returns a new metadata key holding values of type T. The name
+// is only used to describe the key, e.g. in Router.Routes.
+func NewMetaKey[T any](name string) *MetaKey[T] {
+	return &MetaKey[T]{name: name}
+}
+
+// String returns the name of the key.
+func (k *MetaKey[T]) String() string {
+	return k.name
+}
+
+// Set sets the value of the key in the metadata of route, and returns route.
+// See Route.Metadata.
+func (k *MetaKey[T]) Set(route *Route, value T) *Route {
+	return route.Metadata(k, value)
+}
+
+// Get returns the value of the key in the metadata of route. It reports
+// false if the key is not set, or is set to a value which is not of type T.
+func (k *MetaKey[T]) Get(route *Route) (T, bool) {
+	var zero T
+	if route == nil {
+		return zero, false
+	}
+	v, err := route.GetMetadataValue(k)
+	if err != nil {
+		return zero, false
+	}
+	value, ok := v.(T)
+	return value, ok
+}
+
+// GetOr returns the value of the key in the metadata of route, or fallback
+// if the key is not set.
+func (k *MetaKey[T]) GetOr(route *Route, fallback T) T {
+	if value, ok := k.Get(route); ok {
+		return value
+	}
+	return fallback
+}
+
+// FromRequest returns the value of the key in the metadata of the route
+// matched by the request. See CurrentRoute.
+func (k *MetaKey[T]) FromRequest(r *http.Request) (T, bool) {
+	return k.Get(CurrentRoute(r))
+}
@@ -0,0 +1,53 @@
+package mux
+
+import (
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestMetaKey(t *testing.T) {
+	scope := NewMetaKey[string]("RequiredScope")
+	timeout := NewMetaKey[time.Duration]("Timeout")
+	if scope.String() != "RequiredScope" {
+		t.Errorf("Expected %q, got %q", "RequiredScope", scope.String())
+	}
+
+	r := NewRouter()
+	var gotScope string
+	var gotScopeOK, gotTimeoutOK bool
+	route := r.HandleFunc("/admin", func(w http.ResponseWriter, req *http.Request) {
+		gotScope, gotScopeOK = scope.FromRequest(req)
+		_, gotTimeoutOK = timeout.FromRequest(req)
+	})
+	if scope.Set(route, "admin") != route {
+		t.Error("Expected Set to return the route")
+	}
+
+	r.ServeHTTP(NewRecorder(), newRequest(http.MethodGet, "/admin"))
+	if !gotScopeOK || gotScope != "admin" {
+		t.Errorf("Expected %q, got %q (%v)", "admin", gotScope, gotScopeOK)
+	}
+	if gotTimeoutOK {
+		t.Error("Expected timeout not to be set")
+	}
+
+	if got := timeout.GetOr(route, time.Second); got != time.Second {
+		t.Errorf("Expected %v, got %v", time.Second, got)
+	}
+
+	// Keys are compared by identity.
+	if _, ok := NewMetaKey[string]("RequiredScope").Get(route); ok {
+		t.Error("Expected another key with the same name not to be set")
+	}
+
+	// Values of another type are ignored.
+	route.Metadata(timeout, "1s")
+	if _, ok := timeout.Get(route); ok {
+		t.Error("Expected a value of another type to be ignored")
+	}
+
+	if _, ok := scope.FromRequest(newRequest(http.MethodGet, "/")); ok {
+		t.Error("Expected no value without a current route")
+	}
+}
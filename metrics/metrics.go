@@ -0,0 +1,325 @@
+// Copyright 2012 The Gorilla Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package metrics records per-route HTTP metrics of a mux.Router, and
+// exposes them in the Prometheus text exposition format.
+//
+// Requests are labelled with their method, and with the name and the host
+// and path template of the matched route, e.g. "/users/{id}", which keeps
+// the number of series low. The middleware of a Collector is meant to be
+// registered with Router.UseAlways, so that requests matching no route are
+// counted too:
+//
+//	c := metrics.NewCollector(metrics.Options{})
+//	r.UseAlways(c.Middleware)
+//	r.Handle("/metrics", c.Handler())
+//
+// The following metrics are exposed, with "mux" as default namespace:
+//
+//   - mux_http_requests_total, a counter of the handled requests, by method,
+//     route, template and status class ("2xx", "4xx"...).
+//   - mux_http_requests_in_flight, a gauge of the requests being handled, by
+//     method, route and template.
+//   - mux_http_request_duration_seconds, a histogram of the latency of the
+//     requests, by method, route and template.
+//   - mux_http_unmatched_requests_total, a counter of the requests matching
+//     no route, by method and reason ("not_found" or "method_not_allowed").
+//
+// The matched route is read from the request context, so it must not be
+// omitted with Router.OmitRouteFromContext.
+package metrics
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+// DefaultBuckets are the default upper bounds of the latency histogram, in
+// seconds.
+var DefaultBuckets = []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10}
+
+// Options configures a Collector.
+type Options struct {
+	// Namespace prefixes the names of the metrics. It defaults to "mux".
+	Namespace string
+	// Buckets are the upper bounds of the latency histogram, in seconds. They
+	// default to DefaultBuckets.
+	Buckets []float64
+}
+
+// Collector records the metrics of the requests served through its
+// middleware. It is safe for concurrent use.
+type Collector struct {
+	namespace string
+	buckets   []float64
+	now       func() time.Time
+
+	mu        sync.Mutex
+	series    map[seriesKey]*series
+	unmatched map[unmatchedKey]uint64
+}
+
+type seriesKey struct {
+	method, route, template string
+}
+
+type series struct {
+	codes    [5]uint64 // by status class, from 1xx to 5xx
+	inFlight int64
+	buckets  []uint64 // cumulative counts are computed when writing
+	sum      float64
+	count    uint64
+}
+
+type unmatchedKey struct {
+	method, reason string
+}
+
+// NewCollector returns a new Collector.
+func NewCollector(opts Options) *Collector {
+	c := &Collector{
+		namespace: opts.Namespace,
+		buckets:   opts.Buckets,
+		now:       time.Now,
+		series:    make(map[seriesKey]*series),
+		unmatched: make(map[unmatchedKey]uint64),
+	}
+	if c.namespace == "" {
+		c.namespace = "mux"
+	}
+	if c.buckets == nil {
+		c.buckets = DefaultBuckets
+	}
+	c.buckets = append([]float64(nil), c.buckets...)
+	sort.Float64s(c.buckets)
+	return c
+}
+
+// Middleware records the metrics of the requests. It is a mux.MiddlewareFunc.
+func (c *Collector) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key := seriesKey{method: normalizeMethod(r.Method)}
+		if route := mux.CurrentRoute(r); route != nil {
+			key.route = route.GetName()
+			host, _ := route.GetHostTemplate()
+			path, _ := route.GetPathTemplate()
+			key.template = host + path
+		}
+
+		c.mu.Lock()
+		s := c.seriesFor(key)
+		s.inFlight++
+		c.mu.Unlock()
+
+		start := c.now()
+		rec := &statusRecorder{ResponseWriter: w}
+		defer func() {
+			elapsed := c.now().Sub(start).Seconds()
+			code := rec.code
+			if code == 0 {
+				code = http.StatusOK
+			}
+
+			c.mu.Lock()
+			defer c.mu.Unlock()
+			s.inFlight--
+			if class := code/100 - 1; class >= 0 && class < len(s.codes) {
+				s.codes[class]++
+			}
+			for i, b := range c.buckets {
+				if elapsed <= b {
+					s.buckets[i]++
+					break
+				}
+			}
+			s.sum += elapsed
+			s.count++
+			switch mux.MatchError(r) {
+			case mux.ErrNotFound:
+				c.unmatched[unmatchedKey{key.method, "not_found"}]++
+			case mux.ErrMethodMismatch:
+				c.unmatched[unmatchedKey{key.method, "method_not_allowed"}]++
+			}
+		}()
+		next.ServeHTTP(rec, r)
+	})
+}
+
+// seriesFor returns the series of key, creating it if needed. c.mu must be
+// held.
+func (c *Collector) seriesFor(key seriesKey) *series {
+	s, ok := c.series[key]
+	if !ok {
+		s = &series{buckets: make([]uint64, len(c.buckets))}
+		c.series[key] = s
+	}
+	return s
+}
+
+// Handler returns a handler serving the metrics in the Prometheus text
+// exposition format.
+func (c *Collector) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		_ = c.Write(w)
+	})
+}
+
+// Write writes the metrics in the Prometheus text exposition format.
+func (c *Collector) Write(w io.Writer) error {
+	c.mu.Lock()
+	keys := make([]seriesKey, 0, len(c.series))
+	for k := range c.series {
+		keys = append(keys, k)
+	}
+	snapshot := make(map[seriesKey]series, len(c.series))
+	for k, s := range c.series {
+		cp := *s
+		cp.buckets = append([]uint64(nil), s.buckets...)
+		snapshot[k] = cp
+	}
+	unmatchedKeys := make([]unmatchedKey, 0, len(c.unmatched))
+	unmatched := make(map[unmatchedKey]uint64, len(c.unmatched))
+	for k, n := range c.unmatched {
+		unmatchedKeys = append(unmatchedKeys, k)
+		unmatched[k] = n
+	}
+	c.mu.Unlock()
+
+	sort.Slice(keys, func(i, j int) bool {
+		a, b := keys[i], keys[j]
+		if a.template != b.template {
+			return a.template < b.template
+		}
+		if a.route != b.route {
+			return a.route < b.route
+		}
+		return a.method < b.method
+	})
+	sort.Slice(unmatchedKeys, func(i, j int) bool {
+		a, b := unmatchedKeys[i], unmatchedKeys[j]
+		if a.reason != b.reason {
+			return a.reason < b.reason
+		}
+		return a.method < b.method
+	})
+
+	bw := bufio.NewWriter(w)
+	name := c.namespace + "_http_requests_total"
+	writeHeader(bw, name, "counter", "Total number of HTTP requests handled, by route and status class.")
+	for _, k := range keys {
+		s := snapshot[k]
+		for class, n := range s.codes {
+			if n > 0 {
+				fmt.Fprintf(bw, "%s{%s,code=\"%dxx\"} %d\n", name, k.labels(), class+1, n)
+			}
+		}
+	}
+
+	name = c.namespace + "_http_requests_in_flight"
+	writeHeader(bw, name, "gauge", "Number of HTTP requests being handled, by route.")
+	for _, k := range keys {
+		fmt.Fprintf(bw, "%s{%s} %d\n", name, k.labels(), snapshot[k].inFlight)
+	}
+
+	name = c.namespace + "_http_request_duration_seconds"
+	writeHeader(bw, name, "histogram", "Latency of HTTP requests in seconds, by route.")
+	for _, k := range keys {
+		s := snapshot[k]
+		labels := k.labels()
+		var cumulative uint64
+		for i, b := range c.buckets {
+			cumulative += s.buckets[i]
+			fmt.Fprintf(bw, "%s_bucket{%s,le=\"%s\"} %d\n", name, labels, formatFloat(b), cumulative)
+		}
+		fmt.Fprintf(bw, "%s_bucket{%s,le=\"+Inf\"} %d\n", name, labels, s.count)
+		fmt.Fprintf(bw, "%s_sum{%s} %s\n", name, labels, formatFloat(s.sum))
+		fmt.Fprintf(bw, "%s_count{%s} %d\n", name, labels, s.count)
+	}
+
+	name = c.namespace + "_http_unmatched_requests_total"
+	writeHeader(bw, name, "counter", "Total number of HTTP requests matching no route, by reason.")
+	for _, k := range unmatchedKeys {
+		fmt.Fprintf(bw, "%s{method=\"%s\",reason=\"%s\"} %d\n", name, escapeLabel(k.method), k.reason, unmatched[k])
+	}
+	return bw.Flush()
+}
+
+func writeHeader(w io.Writer, name, typ, help string) {
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n", name, help, name, typ)
+}
+
+func (k seriesKey) labels() string {
+	return fmt.Sprintf("method=\"%s\",route=\"%s\",template=\"%s\"",
+		escapeLabel(k.method), escapeLabel(k.route), escapeLabel(k.template))
+}
+
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+// escapeLabel escapes a label value of the text exposition format.
+func escapeLabel(s string) string {
+	return labelEscaper.Replace(s)
+}
+
+func formatFloat(f float64) string {
+	return strconv.FormatFloat(f, 'g', -1, 64)
+}
+
+// normalizeMethod returns method if it is a standard method, or "OTHER", so
+// that arbitrary methods don't create new series.
+func normalizeMethod(method string) string {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodPost, http.MethodPut, http.MethodPatch,
+		http.MethodDelete, http.MethodConnect, http.MethodOptions, http.MethodTrace:
+		return method
+	}
+	return "OTHER"
+}
+
+// statusRecorder records the final status code written to a ResponseWriter.
+type statusRecorder struct {
+	http.ResponseWriter
+	code int
+}
+
+func (r *statusRecorder) WriteHeader(code int) {
+	// Informational responses, such as 103 Early Hints, precede the final
+	// one, except for 101 Switching Protocols.
+	informational := code >= 100 && code < 200 && code != http.StatusSwitchingProtocols
+	if r.code == 0 && !informational {
+		r.code = code
+	}
+	r.ResponseWriter.WriteHeader(code)
+}
+
+func (r *statusRecorder) Write(b []byte) (int, error) {
+	if r.code == 0 {
+		r.code = http.StatusOK
+	}
+	return r.ResponseWriter.Write(b)
+}
+
+// Flush implements http.Flusher if the underlying ResponseWriter does.
+func (r *statusRecorder) Flush() {
+	if f, ok := r.ResponseWriter.(http.Flusher); ok {
+		if r.code == 0 {
+			r.code = http.StatusOK
+		}
+		f.Flush()
+	}
+}
+
+// Unwrap returns the underlying ResponseWriter, for http.ResponseController.
+func (r *statusRecorder) Unwrap() http.ResponseWriter {
+	return r.ResponseWriter
+}
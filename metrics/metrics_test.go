@@ -0,0 +1,147 @@
+package metrics
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+func TestCollector(t *testing.T) {
+	c := NewCollector(Options{Buckets: []float64{1, 0.1}})
+	var clock time.Time
+	c.now = func() time.Time {
+		clock = clock.Add(50 * time.Millisecond)
+		return clock
+	}
+
+	r := mux.NewRouter()
+	r.UseAlways(c.Middleware)
+	var inFlight string
+	r.HandleFunc("/users/{id}", func(w http.ResponseWriter, req *http.Request) {
+		var b strings.Builder
+		_ = c.Write(&b)
+		inFlight = b.String()
+		if mux.Vars(req)["id"] == "0" {
+			http.Error(w, "no such user", http.StatusNotFound)
+		}
+	}).Methods(http.MethodGet).Name("user")
+
+	for _, req := range []struct{ method, path string }{
+		{http.MethodGet, "/users/1"},
+		{http.MethodGet, "/users/2"},
+		{http.MethodGet, "/users/0"},
+		{http.MethodPost, "/users/1"},
+		{http.MethodGet, "/missing"},
+		{"BREW", "/missing"},
+	} {
+		r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(req.method, req.path, nil))
+	}
+
+	if !strings.Contains(inFlight, `mux_http_requests_in_flight{method="GET",route="user",template="/users/{id}"} 1`) {
+		t.Errorf("Expected a request in flight, got:\n%s", inFlight)
+	}
+
+	var b strings.Builder
+	if err := c.Write(&b); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	expected := `# HELP mux_http_requests_total Total number of HTTP requests handled, by route and status class.
+# TYPE mux_http_requests_total counter
+mux_http_requests_total{method="GET",route="",template="",code="4xx"} 1
+mux_http_requests_total{method="OTHER",route="",template="",code="4xx"} 1
+mux_http_requests_total{method="POST",route="",template="",code="4xx"} 1
+mux_http_requests_total{method="GET",route="user",template="/users/{id}",code="2xx"} 2
+mux_http_requests_total{method="GET",route="user",template="/users/{id}",code="4xx"} 1
+# HELP mux_http_requests_in_flight Number of HTTP requests being handled, by route.
+# TYPE mux_http_requests_in_flight gauge
+mux_http_requests_in_flight{method="GET",route="",template=""} 0
+mux_http_requests_in_flight{method="OTHER",route="",template=""} 0
+mux_http_requests_in_flight{method="POST",route="",template=""} 0
+mux_http_requests_in_flight{method="GET",route="user",template="/users/{id}"} 0
+# HELP mux_http_request_duration_seconds Latency of HTTP requests in seconds, by route.
+# TYPE mux_http_request_duration_seconds histogram
+mux_http_request_duration_seconds_bucket{method="GET",route="",template="",le="0.1"} 1
+mux_http_request_duration_seconds_bucket{method="GET",route="",template="",le="1"} 1
+mux_http_request_duration_seconds_bucket{method="GET",route="",template="",le="+Inf"} 1
+mux_http_request_duration_seconds_sum{method="GET",route="",template=""} 0.05
+mux_http_request_duration_seconds_count{method="GET",route="",template=""} 1
+mux_http_request_duration_seconds_bucket{method="OTHER",route="",template="",le="0.1"} 1
+mux_http_request_duration_seconds_bucket{method="OTHER",route="",template="",le="1"} 1
+mux_http_request_duration_seconds_bucket{method="OTHER",route="",template="",le="+Inf"} 1
+mux_http_request_duration_seconds_sum{method="OTHER",route="",template=""} 0.05
+mux_http_request_duration_seconds_count{method="OTHER",route="",template=""} 1
+mux_http_request_duration_seconds_bucket{method="POST",route="",template="",le="0.1"} 1
+mux_http_request_duration_seconds_bucket{method="POST",route="",template="",le="1"} 1
+mux_http_request_duration_seconds_bucket{method="POST",route="",template="",le="+Inf"} 1
+mux_http_request_duration_seconds_sum{method="POST",route="",template=""} 0.05
+mux_http_request_duration_seconds_count{method="POST",route="",template=""} 1
+mux_http_request_duration_seconds_bucket{method="GET",route="user",template="/users/{id}",le="0.1"} 3
+mux_http_request_duration_seconds_bucket{method="GET",route="user",template="/users/{id}",le="1"} 3
+mux_http_request_duration_seconds_bucket{method="GET",route="user",template="/users/{id}",le="+Inf"} 3
+mux_http_request_duration_seconds_sum{method="GET",route="user",template="/users/{id}"} 0.15000000000000002
+mux_http_request_duration_seconds_count{method="GET",route="user",template="/users/{id}"} 3
+# HELP mux_http_unmatched_requests_total Total number of HTTP requests matching no route, by reason.
+# TYPE mux_http_unmatched_requests_total counter
+mux_http_unmatched_requests_total{method="POST",reason="method_not_allowed"} 1
+mux_http_unmatched_requests_total{method="GET",reason="not_found"} 1
+mux_http_unmatched_requests_total{method="OTHER",reason="not_found"} 1
+`
+	if b.String() != expected {
+		t.Errorf("Expected:\n%s\ngot:\n%s", expected, b.String())
+	}
+}
+
+func TestInformationalStatus(t *testing.T) {
+	c := NewCollector(Options{})
+	r := mux.NewRouter()
+	r.UseAlways(c.Middleware)
+	r.HandleFunc("/hints", func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set("Link", "</style.css>; rel=preload; as=style")
+		w.WriteHeader(http.StatusEarlyHints)
+		w.WriteHeader(http.StatusOK)
+	})
+	r.HandleFunc("/upgrade", func(w http.ResponseWriter, req *http.Request) {
+		w.WriteHeader(http.StatusSwitchingProtocols)
+	})
+	r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/hints", nil))
+	r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/upgrade", nil))
+
+	var b strings.Builder
+	if err := c.Write(&b); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	for _, line := range []string{
+		`mux_http_requests_total{method="GET",route="",template="/hints",code="2xx"} 1`,
+		`mux_http_requests_total{method="GET",route="",template="/upgrade",code="1xx"} 1`,
+	} {
+		if !strings.Contains(b.String(), line) {
+			t.Errorf("Expected %q, got:\n%s", line, b.String())
+		}
+	}
+	if strings.Contains(b.String(), `template="/hints",code="1xx"`) {
+		t.Errorf("Expected early hints not to be counted, got:\n%s", b.String())
+	}
+}
+
+func TestHandler(t *testing.T) {
+	c := NewCollector(Options{Namespace: "app"})
+	rw := httptest.NewRecorder()
+	c.Handler().ServeHTTP(rw, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+
+	if ct := rw.Header().Get("Content-Type"); !strings.HasPrefix(ct, "text/plain; version=0.0.4") {
+		t.Errorf("Unexpected content type %q", ct)
+	}
+	if !strings.Contains(rw.Body.String(), "# TYPE app_http_requests_total counter") {
+		t.Errorf("Expected the namespace in metric names, got:\n%s", rw.Body.String())
+	}
+}
+
+func TestEscapeLabel(t *testing.T) {
+	if got, expected := escapeLabel("a\"b\\c\nd"), `a\"b\\c\nd`; got != expected {
+		t.Errorf("Expected %s, got %s", expected, got)
+	}
+}
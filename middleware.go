@@ -27,6 +27,15 @@ func (r *Router) Use(mwf ...MiddlewareFunc) {
 	}
 }
 
+// UseAlways appends a MiddlewareFunc to the chain wrapping the handler of every request served by the router, whether a route matched or not: the handler of the matched route, NotFoundHandler, MethodNotAllowedHandler, and the redirects to clean paths or due to StrictSlash. This is useful for logging, metrics or security headers. The middlewares run in the order they are applied, before the middlewares added with Use, and can get the reason of a match failure with MatchError.
+//
+// Only the router serving the request runs these middlewares: those of the subrouters created with Route.Subrouter are not run.
+func (r *Router) UseAlways(mwf ...MiddlewareFunc) {
+	for _, fn := range mwf {
+		r.alwaysMiddlewares = append(r.alwaysMiddlewares, fn)
+	}
+}
+
 // useInterface appends a middleware to the chain. Middleware can be used to intercept or otherwise modify requests and/or responses, and are executed in the order that they are applied to the Router.
 func (r *Router) useInterface(mw middleware) {
 	r.middlewares = append(r.middlewares, mw)
@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"fmt"
 	"net/http"
+	"reflect"
 	"testing"
 )
 
@@ -675,3 +676,218 @@ func TestMiddlewareOnMultiSubrouter(t *testing.T) {
 		}
 	})
 }
+
+func TestMiddlewareUseAlways(t *testing.T) {
+	router := NewRouter()
+	router.HandleFunc("/", stringHandler("home")).Methods(http.MethodGet)
+	router.HandleFunc("/slash/", stringHandler("slash")).Methods(http.MethodGet)
+	router.StrictSlash(true)
+	router.HandleFunc("/strict/", stringHandler("strict"))
+
+	var order []string
+	var gotErr error
+	var gotRoute *Route
+	router.Use(func(h http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			order = append(order, "use")
+			h.ServeHTTP(w, r)
+		})
+	})
+	router.UseAlways(func(h http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			order = append(order, "always")
+			gotErr = MatchError(r)
+			gotRoute = CurrentRoute(r)
+			w.Header().Set("X-Always", "1")
+			h.ServeHTTP(w, r)
+		})
+	})
+
+	tests := []struct {
+		title      string
+		method     string
+		path       string
+		wantCode   int
+		wantErr    error
+		wantRoute  bool
+		wantOrder  []string
+		wantHeader string
+	}{
+		{"matched route", http.MethodGet, "/", http.StatusOK, nil, true, []string{"always", "use"}, ""},
+		{"not found", http.MethodGet, "/missing", http.StatusNotFound, ErrNotFound, false, []string{"always"}, ""},
+		{"method not allowed", http.MethodPost, "/", http.StatusMethodNotAllowed, ErrMethodMismatch, false, []string{"always"}, ""},
+		{"clean path redirect", http.MethodGet, "/a/../", http.StatusMovedPermanently, nil, false, []string{"always"}, "http://localhost/"},
+		{"strict slash redirect", http.MethodGet, "/strict", http.StatusMovedPermanently, nil, true, []string{"always", "use"}, "http://localhost/strict/"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.title, func(t *testing.T) {
+			order, gotErr, gotRoute = nil, nil, nil
+			rw := NewRecorder()
+			router.ServeHTTP(rw, newRequest(tc.method, "http://localhost"+tc.path))
+			if rw.Code != tc.wantCode {
+				t.Errorf("Expected status %d, got %d", tc.wantCode, rw.Code)
+			}
+			if rw.Header().Get("X-Always") != "1" {
+				t.Error("Expected the middleware to set its header")
+			}
+			if gotErr != tc.wantErr {
+				t.Errorf("Expected match error %v, got %v", tc.wantErr, gotErr)
+			}
+			if (gotRoute != nil) != tc.wantRoute {
+				t.Errorf("Expected a current route: %v, got %v", tc.wantRoute, gotRoute)
+			}
+			if !reflect.DeepEqual(order, tc.wantOrder) {
+				t.Errorf("Expected middlewares %v, got %v", tc.wantOrder, order)
+			}
+			if loc := rw.Header().Get("Location"); loc != tc.wantHeader {
+				t.Errorf("Expected location %q, got %q", tc.wantHeader, loc)
+			}
+		})
+	}
+}
+
+func TestMiddlewareGroups(t *testing.T) {
+	var order []string
+	mw := func(name string) MiddlewareFunc {
+		return func(h http.Handler) http.Handler {
+			return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				order = append(order, name)
+				h.ServeHTTP(w, r)
+			})
+		}
+	}
+
+	router := NewRouter()
+	router.Use(mw("router"))
+	router.HandleFunc("/first", stringHandler("ok"))
+	auth := router.With(mw("auth"))
+	auth.HandleFunc("/account", stringHandler("ok")).Use(mw("route"))
+	admin := auth.With(mw("admin")).StrictSlash(true)
+	admin.HandleFunc("/admin/", stringHandler("ok"))
+	api := router.Group(func(r *Router) {
+		r.Use(mw("api"))
+		r.PathPrefix("/api").Subrouter().HandleFunc("/users", stringHandler("ok"))
+	})
+	router.HandleFunc("/last", stringHandler("ok"))
+
+	tests := []struct {
+		path      string
+		wantCode  int
+		wantOrder []string
+	}{
+		{"/first", http.StatusOK, []string{"router"}},
+		{"/account", http.StatusOK, []string{"router", "auth", "route"}},
+		{"/admin/", http.StatusOK, []string{"router", "auth", "admin"}},
+		{"/admin", http.StatusMovedPermanently, []string{"router", "auth", "admin"}},
+		{"/api/users", http.StatusOK, []string{"router", "api"}},
+		{"/last", http.StatusOK, []string{"router"}},
+		{"/missing", http.StatusNotFound, nil},
+	}
+	for _, tc := range tests {
+		t.Run(tc.path, func(t *testing.T) {
+			order = nil
+			rw := NewRecorder()
+			router.ServeHTTP(rw, newRequest(http.MethodGet, tc.path))
+			if rw.Code != tc.wantCode {
+				t.Errorf("Expected status %d, got %d", tc.wantCode, rw.Code)
+			}
+			if !reflect.DeepEqual(order, tc.wantOrder) {
+				t.Errorf("Expected middlewares %v, got %v", tc.wantOrder, order)
+			}
+		})
+	}
+
+	// Routes of groups are registered on the router, in order.
+	if n := len(router.routes); n != 5 {
+		t.Errorf("Expected 5 routes on the router, got %d", n)
+	}
+	if auth.GroupParent() != router || admin.GroupParent() != auth || router.GroupParent() != nil {
+		t.Error("Unexpected group parents")
+	}
+
+	// Walk reports the group of each route.
+	var routers []*Router
+	var middlewares []int
+	err := router.Walk(func(route *Route, r *Router, ancestors []*Route) error {
+		routers = append(routers, r)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	for _, ri := range router.Routes() {
+		middlewares = append(middlewares, ri.Middlewares)
+	}
+	if len(routers) != 6 || routers[0] != router || routers[1] != auth || routers[2] != admin ||
+		routers[3] != api || routers[4] == api || routers[5] != router {
+		t.Errorf("Unexpected routers %v", routers)
+	}
+	if expected := []int{1, 3, 3, 2, 2, 1}; !reflect.DeepEqual(middlewares, expected) {
+		t.Errorf("Expected middleware counts %v, got %v", expected, middlewares)
+	}
+}
+
+func TestGroupRoutes(t *testing.T) {
+	router := NewRouter()
+	router.HandleFunc("/", stringHandler("home"))
+	auth := router.With()
+	auth.HandleFunc("/account", stringHandler("account"))
+	admin := auth.With()
+	admin.HandleFunc("/admin", stringHandler("admin")).Methods(http.MethodGet)
+	admin.PathPrefix("/tools").Subrouter().HandleFunc("/x", stringHandler("tools"))
+	router.HandleFunc("/last", stringHandler("last"))
+
+	var paths []string
+	err := auth.Walk(func(route *Route, r *Router, ancestors []*Route) error {
+		tpl, _ := route.GetPathTemplate()
+		paths = append(paths, tpl)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if expected := []string{"/account", "/admin", "/tools", "/tools/x"}; !reflect.DeepEqual(paths, expected) {
+		t.Errorf("Expected paths %v, got %v", expected, paths)
+	}
+
+	paths = nil
+	for _, ri := range admin.Routes() {
+		paths = append(paths, ri.Path)
+	}
+	if expected := []string{"/admin", "/tools", "/tools/x"}; !reflect.DeepEqual(paths, expected) {
+		t.Errorf("Expected paths %v, got %v", expected, paths)
+	}
+
+	tests := []struct {
+		group    *Router
+		method   string
+		path     string
+		wantCode int
+		wantBody string
+	}{
+		{auth, http.MethodGet, "/account", http.StatusOK, "account"},
+		{auth, http.MethodGet, "/admin", http.StatusOK, "admin"},
+		{auth, http.MethodGet, "/tools/x", http.StatusOK, "tools"},
+		{auth, http.MethodGet, "/", http.StatusNotFound, ""},
+		{auth, http.MethodGet, "/last", http.StatusNotFound, ""},
+		{admin, http.MethodGet, "/account", http.StatusNotFound, ""},
+		{admin, http.MethodPost, "/admin", http.StatusMethodNotAllowed, ""},
+		{admin, http.MethodGet, "http://localhost//admin", http.StatusMovedPermanently, ""},
+	}
+	for _, tc := range tests {
+		rw := NewRecorder()
+		tc.group.ServeHTTP(rw, newRequest(tc.method, tc.path))
+		if rw.Code != tc.wantCode {
+			t.Errorf("%s %s: Expected status %d, got %d", tc.method, tc.path, tc.wantCode, rw.Code)
+		}
+		if tc.wantBody != "" && rw.Body.String() != tc.wantBody {
+			t.Errorf("%s %s: Expected body %q, got %q", tc.method, tc.path, tc.wantBody, rw.Body.String())
+		}
+	}
+
+	var match RouteMatch
+	if admin.Match(newRequest(http.MethodGet, "/account"), &match) {
+		t.Errorf("Expected no match for a route of another group")
+	}
+}
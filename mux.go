@@ -8,10 +8,12 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"net"
 	"net/http"
 	"net/url"
 	"path"
 	"regexp"
+	"strings"
 )
 
 var (
@@ -74,6 +76,27 @@ type Router struct {
 	// Slice of middlewares to be called after a match is found
 	middlewares []middleware
 
+	// Slice of middlewares wrapping the handler of every request served by
+	// the router, whether a route matched or not
+	alwaysMiddlewares []middleware
+
+	// For a group created with With or Group, the router or group it was
+	// created from
+	parent *Router
+
+	// Observers notified of the routing decisions of the router
+	observers []RouterObserver
+
+	// Functions rewriting requests before they are matched
+	rewrites []RewriteFunc
+
+	// The forwarding request headers used to build absolute URLs, if any
+	forwardedHeaders ForwardedHeaders
+
+	// Status code of the redirects to clean paths, 0 meaning
+	// http.StatusMovedPermanently
+	cleanPathCode int
+
 	// configuration shared with `Route`
 	routeConf
 }
@@ -91,6 +114,19 @@ type routeConf struct {
 	// will not redirect
 	skipClean bool
 
+	// Status code of the redirects due to StrictSlash, 0 meaning
+	// http.StatusMovedPermanently.
+	strictSlashCode int
+
+	// If true, the path pattern "/Path" matches "/path" and "/PATH" too, and
+	// redirects them with caseRedirectCode if it is a redirect code.
+	caseInsensitivePath bool
+	caseRedirectCode    int
+
+	// If true, when the path pattern is "/path/", accessing "/path" will
+	// match it without redirecting and vice versa.
+	optionalTrailingSlash bool
+
 	// If true, the http.Request context will not contain the Route.
 	omitRouteFromContext bool
 
@@ -110,6 +146,17 @@ type routeConf struct {
 	// The scheme used when building URLs.
 	buildScheme string
 
+	// Variables supplied from the current request when building URLs with
+	// URLFor.
+	stickyVars []string
+
+	// Prefix of the names of the routes, e.g. "billing.invoices".
+	namespace string
+
+	// Metadata inherited from the routers enclosing the route. The map is
+	// never modified once set, so it is shared by copies.
+	inheritedMetadata map[any]any
+
 	buildVarsFunc BuildVarsFunc
 }
 
@@ -133,6 +180,11 @@ func copyRouteConf(r routeConf) routeConf {
 	c.matchers = make([]matcher, len(r.matchers))
 	copy(c.matchers, r.matchers)
 
+	if r.stickyVars != nil {
+		c.stickyVars = make([]string, len(r.stickyVars))
+		copy(c.stickyVars, r.stickyVars)
+	}
+
 	return c
 }
 
@@ -152,11 +204,24 @@ func copyRouteRegexp(r *routeRegexp) *routeRegexp {
 // will be filled in the match argument's MatchErr field. If the match failure type
 // (eg: not found) has a registered handler, the handler is assigned to the Handler
 // field of the match argument.
+//
+// A group created with With or Group matches the routes it registered, with
+// the handlers of the router owning them.
 func (r *Router) Match(req *http.Request, match *RouteMatch) bool {
+	return r.owner().match(req, match, r)
+}
+
+// match matches req against the routes of the router registered by scope,
+// either the router or one of its groups.
+func (r *Router) match(req *http.Request, match *RouteMatch, scope *Router) bool {
 	for _, route := range r.routes {
+		if !scope.registered(route) {
+			continue
+		}
 		if route.Match(req, match) {
 			// Build middleware chain if no error was found
 			if match.MatchErr == nil {
+				match.Handler = route.wrapGroupMiddlewares(match.Handler)
 				for i := len(r.middlewares) - 1; i >= 0; i-- {
 					match.Handler = r.middlewares[i].Middleware(match.Handler)
 				}
@@ -189,23 +254,79 @@ func (r *Router) Match(req *http.Request, match *RouteMatch) bool {
 //
 // When there is a match, the route variables can be retrieved calling
 // mux.Vars(request).
+//
+// A group created with With or Group serves the routes it registered like
+// the router owning them.
 func (r *Router) ServeHTTP(w http.ResponseWriter, req *http.Request) {
-	if !r.skipClean {
-		path := req.URL.Path
-		if r.useEncodedPath {
-			path = req.URL.EscapedPath()
+	r.owner().serve(w, req, r)
+}
+
+// serve serves req with the routes of the router registered by scope, see
+// Router.match.
+func (r *Router) serve(w http.ResponseWriter, req *http.Request, scope *Router) {
+	if len(r.rewrites) > 0 {
+		req = withOriginal(req)
+	}
+	handler, req := r.clean(req)
+	if handler == nil {
+		if len(r.rewrites) > 0 {
+			req = r.rewrite(req)
 		}
-		// Clean path to canonical form and redirect.
-		if p := cleanPath(path); p != path {
-			w.Header().Set("Location", replaceURLPath(req.URL, p))
-			w.WriteHeader(http.StatusMovedPermanently)
-			return
+		handler, req = r.handler(req, scope)
+	}
+	for i := len(r.alwaysMiddlewares) - 1; i >= 0; i-- {
+		handler = r.alwaysMiddlewares[i].Middleware(handler)
+	}
+	handler.ServeHTTP(w, req)
+}
+
+// clean cleans the path of req to canonical form, unless the router skips
+// it. It returns a handler redirecting to the clean path, or nil and req
+// with the clean path if it is an internal redirect.
+func (r *Router) clean(req *http.Request) (http.Handler, *http.Request) {
+	if r.skipClean {
+		return nil, req
+	}
+	path := req.URL.Path
+	if r.useEncodedPath {
+		path = req.URL.EscapedPath()
+	}
+	p := cleanPath(path)
+	if p == path {
+		return nil, req
+	}
+	escaped := r.useEncodedPath
+	if !escaped {
+		// Keep the encoding of the path, e.g. "%2F", unless only its
+		// decoded form needs cleaning.
+		if ep := cleanPath(req.URL.EscapedPath()); ep != req.URL.EscapedPath() {
+			p, escaped = ep, true
 		}
 	}
+	code := redirectCode(r.cleanPathCode)
+	if code == InternalRedirect {
+		return nil, withCleanPath(req, p, escaped)
+	}
+	location := replaceURLPath(req.URL, p, escaped)
+	for _, o := range r.observers {
+		o.OnRedirect(req, location, code)
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set("Location", location)
+		w.WriteHeader(code)
+	}), req
+}
+
+// handler returns the handler serving req with the routes registered by
+// scope, and req with the result of the match in its context.
+func (r *Router) handler(req *http.Request, scope *Router) (http.Handler, *http.Request) {
 	var match RouteMatch
 	var handler http.Handler
-	if r.Match(req, &match) {
+	if r.match(req, &match, scope) {
 		handler = match.Handler
+		if match.internalPath != "" {
+			req = withCleanPath(req, match.internalPath, true)
+		}
 		if handler != nil {
 			// Populate context for custom handlers
 			if r.omitRouteFromContext {
@@ -221,6 +342,14 @@ func (r *Router) ServeHTTP(w http.ResponseWriter, req *http.Request) {
 		}
 	}
 
+	if match.MatchErr != nil {
+		req = requestWithMatchErr(req, match.MatchErr)
+	}
+
+	if len(r.observers) > 0 {
+		r.notify(req, &match)
+	}
+
 	if handler == nil && match.MatchErr == ErrMethodMismatch {
 		handler = methodNotAllowedHandler()
 	}
@@ -229,18 +358,48 @@ func (r *Router) ServeHTTP(w http.ResponseWriter, req *http.Request) {
 		handler = http.NotFoundHandler()
 	}
 
-	handler.ServeHTTP(w, req)
+	return handler, req
 }
 
 // Get returns a route registered with the given name.
+//
+// If the router has a namespace (see Router.Namespace), the name is first
+// looked up relative to the namespace and each of its parents, from the
+// innermost outwards, and then as a fully qualified name. For instance, in
+// the "billing.invoices" namespace, Get("list") returns the first route named
+// "billing.invoices.list", "billing.list" or "list".
 func (r *Router) Get(name string) *Route {
+	for ns := r.namespace; ns != ""; ns = parentNamespace(ns) {
+		if route, ok := r.namedRoutes[ns+"."+name]; ok {
+			return route
+		}
+	}
 	return r.namedRoutes[name]
 }
 
 // GetRoute returns a route registered with the given name. This method
 // was renamed to Get() and remains here for backwards compatibility.
 func (r *Router) GetRoute(name string) *Route {
-	return r.namedRoutes[name]
+	return r.Get(name)
+}
+
+// Namespace appends a namespace to the names of new routes. The names of the
+// routes registered after this call are prefixed with the namespace and a
+// dot, e.g. "billing.list" for a route named "list" in the "billing"
+// namespace. Namespaces nest: subrouters inherit the namespace of their
+// parent, to which they can append their own.
+//
+//	billing := r.PathPrefix("/billing").Subrouter().Namespace("billing")
+//	billing.HandleFunc("/", ListHandler).Name("list")
+//
+//	// Both return the route named "billing.list".
+//	r.Get("billing.list")
+//	billing.Get("list")
+//
+// See Router.Get for how names are looked up.
+func (r *Router) Namespace(name string) *Router {
+	r.namespace = qualifiedName(r.namespace, name)
+	return r
 }
 
 // StrictSlash defines the trailing slash behavior for new routes. The initial
@@ -253,10 +412,11 @@ func (r *Router) GetRoute(name string) *Route {
 // When false, if the route path is "/path", accessing "/path/" will not match
 // this route and vice versa.
 //
-// The redirect is a HTTP 301 (Moved Permanently). Note that when this is set for
-// routes with a non-idempotent method (e.g. POST, PUT), the subsequent redirected
-// request will be made as a GET by most clients. Use middleware or client settings
-// to modify this behaviour as needed.
+// The redirect is a HTTP 301 (Moved Permanently) by default. Note that when this
+// is set for routes with a non-idempotent method (e.g. POST, PUT), the subsequent
+// redirected request will be made as a GET by most clients. Use
+// StrictSlashRedirect to redirect with 307 or 308 instead, which preserve the
+// method, or to serve the request without redirecting.
 //
 // Special case: when a route sets a path prefix using the PathPrefix() method,
 // strict slash is ignored for that route because the redirect behavior can't
@@ -267,6 +427,109 @@ func (r *Router) StrictSlash(value bool) *Router {
 	return r
 }
 
+// InternalRedirect can be given to CleanPathRedirect and StrictSlashRedirect
+// to serve requests without redirecting them.
+const InternalRedirect = -1
+
+// StrictSlashRedirect sets the status code of the redirects of new routes due
+// to StrictSlash. It can be 301, 302, 303, 307 or 308, such as
+// http.StatusPermanentRedirect (308) which preserves the method and body of
+// the request, or InternalRedirect to let the route handle the request as if
+// it had the path of the route, without redirecting. The initial value is
+// http.StatusMovedPermanently (301).
+//
+// It panics if code is neither a redirect code nor InternalRedirect.
+func (r *Router) StrictSlashRedirect(code int) *Router {
+	checkRedirectCode(code)
+	r.strictSlashCode = code
+	return r
+}
+
+// CleanPathRedirect sets the status code of the redirects to clean paths, see
+// SkipClean. It can be 301, 302, 303, 307 or 308, or InternalRedirect to
+// match and handle the request as if it had the clean path. The initial value
+// is http.StatusMovedPermanently (301).
+//
+// It panics if code is neither a redirect code nor InternalRedirect.
+func (r *Router) CleanPathRedirect(code int) *Router {
+	checkRedirectCode(code)
+	r.cleanPathCode = code
+	return r
+}
+
+func checkRedirectCode(code int) {
+	if code != InternalRedirect {
+		if err := validRedirectCode(code); err != nil {
+			panic(err)
+		}
+	}
+}
+
+// validRedirectCode returns an error if code isn't the status code of a
+// redirect: 301, 302, 303, 307 or 308.
+func validRedirectCode(code int) error {
+	switch code {
+	case http.StatusMovedPermanently, http.StatusFound, http.StatusSeeOther,
+		http.StatusTemporaryRedirect, http.StatusPermanentRedirect:
+		return nil
+	}
+	return fmt.Errorf("mux: invalid redirect status code %d", code)
+}
+
+// redirectCode returns the configured redirect code, or the default one.
+func redirectCode(code int) int {
+	if code == 0 {
+		return http.StatusMovedPermanently
+	}
+	return code
+}
+
+// CaseInsensitivePath defines the case sensitivity of the paths of new routes.
+// The initial value is false.
+//
+// When true, the fixed parts of the path templates match regardless of case:
+// the route path "/Users/{name}" matches "/users/Bob" and "/USERS/Bob". The
+// patterns of the variables keep their own case sensitivity, so that
+// "{name:[a-z]+}" still only matches lowercase names. Such requests are
+// handled as is unless CanonicalCaseRedirect is set. URLs are built with the
+// case of the templates.
+func (r *Router) CaseInsensitivePath(value bool) *Router {
+	r.caseInsensitivePath = value
+	return r
+}
+
+// CanonicalCaseRedirect sets the status code of the redirects of requests
+// matching the path of a new route case-insensitively, see
+// CaseInsensitivePath, to the path with the case of the template. The path
+// "/USERS/Bob" is redirected to "/Users/Bob" for the route path
+// "/Users/{name}". It can be 301, 302, 303, 307 or 308, or InternalRedirect
+// to handle requests without redirecting them, which is the initial
+// behaviour.
+//
+// Paths which also need a trailing slash fix due to StrictSlash, such as
+// "/USERS/Bob/", are redirected once, to "/Users/Bob", with this code rather
+// than the one set with StrictSlashRedirect, so that a temporary case
+// redirect isn't made permanent.
+//
+// It panics if code is neither a redirect code nor InternalRedirect.
+func (r *Router) CanonicalCaseRedirect(code int) *Router {
+	checkRedirectCode(code)
+	r.caseRedirectCode = code
+	return r
+}
+
+// OptionalTrailingSlash defines the trailing slash behavior for new routes.
+// The initial value is false.
+//
+// When true, if the route path is "/path/", accessing "/path" will match this
+// route without redirecting and vice versa, and the route handles both paths.
+// URLs are built with the trailing slash of the template. It takes precedence
+// over StrictSlash, and is ignored for path prefixes.
+func (r *Router) OptionalTrailingSlash(value bool) *Router {
+	r.optionalTrailingSlash = value
+	return r
+}
+
 // SkipClean defines the path cleaning behaviour for new routes. The initial
 // value is false. Users should be careful about which routes are not cleaned
 //
@@ -317,6 +580,85 @@ func (r *Router) StrictQueryParamSep(value bool) *Router {
 	return r
 }
 
+// ForwardedHeaders is a family of request headers set by proxies to forward
+// the scheme and host of the requests of clients. See
+// Router.TrustForwardedHeaders.
+type ForwardedHeaders int
+
+const (
+	// NoForwardedHeaders ignores forwarding headers.
+	NoForwardedHeaders ForwardedHeaders = iota
+	// XForwardedHeaders uses the X-Forwarded-Proto and X-Forwarded-Host
+	// headers, set by most proxies and load balancers.
+	XForwardedHeaders
+	// RFC7239Headers uses the proto and host parameters of the Forwarded
+	// header, defined by RFC 7239.
+	RFC7239Headers
+)
+
+// TrustForwardedHeaders defines which forwarding request headers, if any, are
+// used to fill in the scheme and host of URLs built by Route.AbsoluteURL and
+// URLFor. The initial value is NoForwardedHeaders.
+//
+// Unlike StrictSlash and similar settings, it applies to all the routes of
+// the router and its subrouters, whenever they were registered: it is read
+// from the router serving the request, see CurrentRouter. Forwarding headers
+// are thus ignored if the router is omitted from the request context.
+//
+// Only one family of headers is trusted, so that a client can't make up the
+// headers of the other family when the proxy only sets or strips the trusted
+// one: nginx and most cloud load balancers set X-Forwarded-* headers, but pass
+// the Forwarded header of the client through. Only set this when the router is
+// deployed behind a proxy which sets or strips the given headers, as they are
+// otherwise under the control of the client.
+//
+// Only the "http" and "https" schemes, and valid hosts with an optional port,
+// are taken from the headers. The scheme and host of the request are used
+// instead of invalid values.
+func (r *Router) TrustForwardedHeaders(headers ForwardedHeaders) *Router {
+	r.forwardedHeaders = headers
+	return r
+}
+
+// StickyVars marks route variables as sticky for new routes: when building a
+// URL for such a route with URLFor, the values of sticky variables that are
+// not given explicitly are taken from the variables of the current request.
+// This is useful for variables shared by a whole tree of routes, such as a
+// tenant or a locale prefix:
+//
+//	s := r.PathPrefix("/{tenant}/{lang}").Subrouter().StickyVars("tenant", "lang")
+//	s.HandleFunc("/articles/{id}", ArticleHandler).Name("article")
+//
+//	// In a handler serving "/acme/en/...", u is "http://host/acme/en/articles/42"
+//	u, err := mux.URLFor(req, "article", "id", "42")
+//
+// Sticky variables are supplied before the BuildVarsFunc of the route, if
+// any, is invoked.
+func (r *Router) StickyVars(names ...string) *Router {
+	r.stickyVars = append(r.stickyVars, names...)
+	return r
+}
+
+// Metadata sets metadata inherited by the routes registered afterwards on the
+// router and its subrouters, e.g. to declare that every route under a prefix
+// requires a role:
+//
+//	admin := r.PathPrefix("/admin").Subrouter().Metadata("role", "admin")
+//
+// The metadata of a route created with Route.Subrouter is inherited as well.
+// Values set on routes or nested routers override inherited ones. See
+// Route.GetEffectiveMetadata.
+func (r *Router) Metadata(key any, value any) *Router {
+	r.inheritedMetadata = mergeMetadata(r.inheritedMetadata, map[any]any{key: value})
+	return r
+}
+
+// GetMetadata returns a copy of the metadata inherited by the routes of the
+// router.
+func (r *Router) GetMetadata() map[any]any {
+	return mergeMetadata(nil, r.inheritedMetadata)
+}
+
 // UseEncodedPath tells the router to match the encoded original path
 // to the routes.
 // For eg. "/path/foo%2Fbar/to" will match the path "/path/{var}/to".
@@ -336,10 +678,81 @@ func (r *Router) UseEncodedPath() *Router {
 func (r *Router) NewRoute() *Route {
 	// initialize a route with a copy of the parent router's configuration
 	route := &Route{routeConf: copyRouteConf(r.routeConf), namedRoutes: r.namedRoutes}
-	r.routes = append(r.routes, route)
+	if r.parent != nil {
+		// routes of groups are registered on the router owning the group
+		route.group = r
+	}
+	owner := r.owner()
+	owner.routes = append(owner.routes, route)
 	return route
 }
 
+// With returns a group registering routes on the router, after the routes
+// registered so far, with the given middlewares. Unlike Route.Subrouter, a
+// group adds no matching condition: it is only a registration scope, whose
+// middlewares wrap the handlers of the routes it registers, after the
+// middlewares of the router and of enclosing groups:
+//
+//	r.HandleFunc("/", HomeHandler)
+//	auth := r.With(AuthMiddleware)
+//	auth.HandleFunc("/account", AccountHandler)
+//	auth.With(AdminMiddleware).HandleFunc("/admin", AdminHandler)
+//
+// Groups start with a copy of the configuration of the router, e.g.
+// StrictSlash or Namespace, which can be changed for the routes they
+// register. Their Match, ServeHTTP, Walk, Routes and WriteDOT methods are
+// restricted to the routes they registered, with the settings of the router
+// owning them, so their NotFoundHandler, MethodNotAllowedHandler and
+// UseAlways middlewares are ignored.
+func (r *Router) With(mwf ...MiddlewareFunc) *Router {
+	group := &Router{routeConf: copyRouteConf(r.routeConf), namedRoutes: r.namedRoutes, parent: r}
+	group.Use(mwf...)
+	return group
+}
+
+// Group calls fn with a new group of the router, see Router.With, and returns
+// the group:
+//
+//	r.Group(func(r *mux.Router) {
+//		r.Use(AuthMiddleware)
+//		r.HandleFunc("/account", AccountHandler)
+//	})
+func (r *Router) Group(fn func(*Router)) *Router {
+	group := r.With()
+	fn(group)
+	return group
+}
+
+// GroupParent returns the router or group from which a group was created
+// with With or Group, or nil if r is not a group.
+func (r *Router) GroupParent() *Router {
+	return r.parent
+}
+
+// owner returns the router owning the routes registered by the group r, or r
+// if it isn't a group.
+func (r *Router) owner() *Router {
+	for r.parent != nil {
+		r = r.parent
+	}
+	return r
+}
+
+// registered reports whether route was registered by the group r or one of
+// its groups. Every route of a router which isn't a group is registered by
+// it.
+func (r *Router) registered(route *Route) bool {
+	if r.parent == nil {
+		return true
+	}
+	for g := route.group; g != nil; g = g.parent {
+		if g == r {
+			return true
+		}
+	}
+	return false
+}
+
 // Name registers a new route with a name.
 // See Route.Name().
 func (r *Router) Name(name string) *Route {
@@ -416,7 +829,17 @@ func (r *Router) BuildVarsFunc(f BuildVarsFunc) *Route {
 // Walk walks the router and all its sub-routers, calling walkFn for each route
 // in the tree. The routes are walked in the order they were added. Sub-routers
 // are explored depth-first.
+//
+// A group created with With or Group walks the routes it registered.
 func (r *Router) Walk(walkFn WalkFunc) error {
+	if r.parent != nil {
+		return r.owner().walk(func(route *Route, router *Router, ancestors []*Route) error {
+			if len(ancestors) == 0 && !r.registered(route) {
+				return SkipRouter
+			}
+			return walkFn(route, router, ancestors)
+		}, []*Route{})
+	}
 	return r.walk(walkFn, []*Route{})
 }
 
@@ -426,12 +849,17 @@ var SkipRouter = errors.New("skip this router")
 
 // WalkFunc is the type of the function called for each route visited by Walk.
 // At every invocation, it is given the current route, and the current router,
-// and a list of ancestor routes that lead to the current route.
+// and a list of ancestor routes that lead to the current route. For routes
+// registered through a group, the router is the group, see Router.GroupParent.
 type WalkFunc func(route *Route, router *Router, ancestors []*Route) error
 
 func (r *Router) walk(walkFn WalkFunc, ancestors []*Route) error {
 	for _, t := range r.routes {
-		err := walkFn(t, r, ancestors)
+		router := r
+		if t.group != nil {
+			router = t.group
+		}
+		err := walkFn(t, router, ancestors)
 		if err == SkipRouter {
 			continue
 		}
@@ -474,6 +902,14 @@ type RouteMatch struct {
 	// It is set to ErrMethodMismatch if there is a mismatch in
 	// the request method and route method
 	MatchErr error
+
+	// Location and status code of the StrictSlash redirect, if any.
+	redirectURL  string
+	redirectCode int
+
+	// Escaped path the request is served with instead of its own, due to
+	// StrictSlashRedirect(InternalRedirect), if any.
+	internalPath string
 }
 
 type contextKey int
@@ -482,6 +918,8 @@ const (
 	varsKey contextKey = iota
 	routeKey
 	routerKey
+	matchErrKey
+	originalKey
 )
 
 // Vars returns the route variables for the current request, if any.
@@ -510,6 +948,36 @@ func CurrentRouter(r *http.Request) *Router {
 	return nil
 }
 
+// MatchError returns the reason why the current request didn't match any
+// route, such as ErrNotFound or ErrMethodMismatch, or nil if it matched.
+// This is useful in middlewares added with Router.UseAlways.
+func MatchError(r *http.Request) error {
+	if rv := r.Context().Value(matchErrKey); rv != nil {
+		return rv.(error)
+	}
+	return nil
+}
+
+// URLFor builds an absolute URL for the route registered with the given name
+// in the router of the current request. See Route.AbsoluteURL().
+//
+// Sticky variables of the route which are not given in pairs are taken from
+// the variables of the request, see Router.StickyVars.
+//
+// An error is returned if the router is not in the request context, see
+// Router.OmitRouterFromContext, or if it has no route with the given name.
+func URLFor(r *http.Request, name string, pairs ...string) (*url.URL, error) {
+	router := CurrentRouter(r)
+	if router == nil {
+		return nil, errors.New("mux: no router in the request context")
+	}
+	route, err := router.getRoute(name)
+	if err != nil {
+		return nil, err
+	}
+	return route.AbsoluteURL(r, route.addStickyVars(r, pairs)...)
+}
+
 // requestWithVars adds the matched vars to the request ctx.
 // It shortcuts the operation when the vars are empty.
 func requestWithVars(r *http.Request, vars map[string]string) *http.Request {
@@ -537,6 +1005,11 @@ func requestWithRouter(r *http.Request, router *Router) *http.Request {
 	return r.WithContext(ctx)
 }
 
+func requestWithMatchErr(r *http.Request, err error) *http.Request {
+	ctx := context.WithValue(r.Context(), matchErrKey, err)
+	return r.WithContext(ctx)
+}
+
 // ----------------------------------------------------------------------------
 // Helpers
 // ----------------------------------------------------------------------------
@@ -561,13 +1034,155 @@ func cleanPath(p string) string {
 }
 
 // replaceURLPath prints an url.URL with a different path.
-func replaceURLPath(u *url.URL, p string) string {
+func replaceURLPath(u *url.URL, p string, escaped bool) string {
 	// Operate on a copy of the request url.
 	u2 := *u
-	u2.Path = p
+	setPath(&u2, p, escaped)
 	return u2.String()
 }
 
+// setPath sets the path of u to p, given in its escaped form if escaped is
+// true, keeping its encoding.
+func setPath(u *url.URL, p string, escaped bool) {
+	u.Path, u.RawPath = p, ""
+	if escaped {
+		if unescaped, err := url.PathUnescape(p); err == nil {
+			u.Path, u.RawPath = unescaped, p
+		}
+	}
+}
+
+// withCleanPath returns a shallow copy of req with its path replaced by the
+// canonical path p, given in its escaped form if escaped is true.
+func withCleanPath(req *http.Request, p string, escaped bool) *http.Request {
+	r2 := new(http.Request)
+	*r2 = *req
+	u := *req.URL
+	setPath(&u, p, escaped)
+	r2.URL = &u
+	return r2
+}
+
+// requestScheme returns the scheme of the request, taken from the trusted
+// forwarding headers if valid, from the request URL if set, or else from the
+// TLS termination state.
+func requestScheme(r *http.Request, headers ForwardedHeaders) string {
+	var proto string
+	switch headers {
+	case XForwardedHeaders:
+		proto = firstHeaderValue(r, "X-Forwarded-Proto")
+	case RFC7239Headers:
+		proto = forwardedParam(r, "proto")
+	}
+	if proto = strings.ToLower(proto); proto == "http" || proto == "https" {
+		return proto
+	}
+	if r.URL.Scheme != "" {
+		return r.URL.Scheme
+	}
+	if r.TLS != nil {
+		return "https"
+	}
+	return "http"
+}
+
+// requestHost returns the host of the request, taken from the trusted
+// forwarding headers if valid, or else as matched by host routes.
+func requestHost(r *http.Request, headers ForwardedHeaders) string {
+	var host string
+	switch headers {
+	case XForwardedHeaders:
+		host = firstHeaderValue(r, "X-Forwarded-Host")
+	case RFC7239Headers:
+		host = forwardedParam(r, "host")
+	}
+	if validHost(host) {
+		return host
+	}
+	return getHost(r)
+}
+
+// validHost reports whether h is a host name or an IP address, with an
+// optional port. IPv6 addresses must be enclosed in square brackets.
+func validHost(h string) bool {
+	host := h
+	if strings.HasPrefix(h, "[") && strings.HasSuffix(h, "]") {
+		host = h[1 : len(h)-1]
+		return strings.Contains(host, ":") && net.ParseIP(host) != nil
+	}
+	if strings.Contains(h, ":") {
+		var port string
+		var err error
+		if host, port, err = net.SplitHostPort(h); err != nil || !validPort(port) {
+			return false
+		}
+		if strings.Contains(host, ":") {
+			return net.ParseIP(host) != nil
+		}
+	}
+	if host == "" {
+		return false
+	}
+	for i := 0; i < len(host); i++ {
+		c := host[i]
+		if !('a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' || '0' <= c && c <= '9' ||
+			c == '-' || c == '.' || c == '_') {
+			return false
+		}
+	}
+	return true
+}
+
+// validPort reports whether port is a decimal port number.
+func validPort(port string) bool {
+	if port == "" || len(port) > 5 {
+		return false
+	}
+	for i := 0; i < len(port); i++ {
+		if port[i] < '0' || port[i] > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// forwardedParam returns the value of a parameter of the first element of
+// the Forwarded header, as defined by RFC 7239.
+func forwardedParam(r *http.Request, name string) string {
+	element, _, _ := strings.Cut(r.Header.Get("Forwarded"), ",")
+	for _, pair := range strings.Split(element, ";") {
+		key, value, ok := strings.Cut(strings.TrimSpace(pair), "=")
+		if ok && strings.EqualFold(key, name) {
+			return strings.Trim(value, `"`)
+		}
+	}
+	return ""
+}
+
+// firstHeaderValue returns the first of the comma-separated values of a
+// request header.
+func firstHeaderValue(r *http.Request, key string) string {
+	value, _, _ := strings.Cut(r.Header.Get(key), ",")
+	return strings.TrimSpace(value)
+}
+
+// qualifiedName returns name prefixed with the namespace ns, if any.
+func qualifiedName(ns, name string) string {
+	if ns == "" {
+		return name
+	}
+	return ns + "." + name
+}
+
+// parentNamespace returns the namespace enclosing ns, or an empty string if
+// there is none.
+func parentNamespace(ns string) string {
+	if i := strings.LastIndex(ns, "."); i != -1 {
+		return ns[:i]
+	}
+	return ""
+}
+
 // uniqueVars returns an error if two slices contain duplicated strings.
 func uniqueVars(s1, s2 []string) error {
 	for _, v1 := range s1 {
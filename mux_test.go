@@ -1466,6 +1466,70 @@ func TestNameMultipleCalls(t *testing.T) {
 	}
 }
 
+func TestNameCollision(t *testing.T) {
+	r := NewRouter()
+	first := r.NewRoute().Name("list")
+	second := r.PathPrefix("/sub").Subrouter().NewRoute().Name("list")
+
+	if err := first.GetError(); err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+	if second.GetError() == nil {
+		t.Error("Expected an error for a duplicated route name")
+	}
+	if r.Get("list") != first {
+		t.Error("Expected the first route to keep its name")
+	}
+}
+
+func TestNamespaces(t *testing.T) {
+	r := NewRouter()
+	home := r.NewRoute().Name("home")
+	list := r.NewRoute().Name("list")
+
+	billing := r.PathPrefix("/billing").Subrouter().Namespace("billing")
+	billingList := billing.NewRoute().Name("list")
+	invoices := billing.PathPrefix("/invoices").Subrouter().Namespace("invoices")
+	invoicesShow := invoices.NewRoute().Name("show")
+
+	shipping := r.PathPrefix("/shipping").Subrouter().Namespace("shipping")
+	shippingList := shipping.NewRoute().Name("list")
+
+	for _, route := range []*Route{home, list, billingList, invoicesShow, shippingList} {
+		if err := route.GetError(); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+	}
+
+	tests := []struct {
+		router   *Router
+		name     string
+		expected *Route
+	}{
+		{r, "list", list},
+		{r, "billing.list", billingList},
+		{r, "billing.invoices.show", invoicesShow},
+		{r, "show", nil},
+		{billing, "list", billingList},
+		{billing, "home", home},
+		{billing, "invoices.show", invoicesShow},
+		{billing, "shipping.list", shippingList},
+		{invoices, "show", invoicesShow},
+		{invoices, "list", billingList},
+		{shipping, "list", shippingList},
+	}
+
+	for _, tc := range tests {
+		if got := tc.router.Get(tc.name); got != tc.expected {
+			t.Errorf("Get(%q) in namespace %q returned the wrong route", tc.name, tc.router.namespace)
+		}
+	}
+
+	if name := invoicesShow.GetName(); name != "billing.invoices.show" {
+		t.Errorf("Expected fully qualified name, got %q", name)
+	}
+}
+
 func TestStrictSlash(t *testing.T) {
 	r := NewRouter()
 	r.StrictSlash(true)
@@ -1837,6 +1901,66 @@ func TestRouterInContext(t *testing.T) {
 	})
 }
 
+func TestURLFor(t *testing.T) {
+	router := NewRouter()
+	router.HandleFunc("/articles/{id}", nil).Name("article")
+	router.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		u, err := URLFor(r, "article", "id", "42")
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if expected := "http://example.com/articles/42"; u.String() != expected {
+			t.Errorf("Expected %q, got %q", expected, u.String())
+		}
+
+		if _, err := URLFor(r, "unknown"); err == nil {
+			t.Error("Expected an error for an unknown route")
+		}
+	})
+
+	router.ServeHTTP(NewRecorder(), newRequestHost("GET", "/", "example.com"))
+
+	if _, err := URLFor(newRequest("GET", "/"), "article", "id", "42"); err == nil {
+		t.Error("Expected an error for a request without router")
+	}
+}
+
+func TestURLForStickyVars(t *testing.T) {
+	router := NewRouter()
+	router.HandleFunc("/about", nil).Name("about")
+	s := router.PathPrefix("/{tenant}/{lang}").Subrouter().StickyVars("tenant", "lang")
+	s.HandleFunc("/articles/{id}", nil).Name("article")
+	s.HandleFunc("/home", nil).
+		BuildVarsFunc(func(vars map[string]string) map[string]string {
+			vars["lang"] = strings.ToLower(vars["lang"])
+			return vars
+		}).
+		Name("home")
+
+	var got []string
+	s.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		for _, args := range [][]string{
+			{"article", "id", "42"},
+			{"article", "id", "42", "lang", "fr"},
+			{"home"},
+			{"about"},
+		} {
+			u, err := URLFor(r, args[0], args[1:]...)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			got = append(got, u.Path)
+		}
+	})
+
+	router.ServeHTTP(NewRecorder(), newRequest("GET", "http://localhost/acme/EN/"))
+
+	expected := []string{"/acme/EN/articles/42", "/acme/fr/articles/42", "/acme/en/home", "/about"}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("Expected %v, got %v", expected, got)
+	}
+}
+
 // ----------------------------------------------------------------------------
 // Helpers
 // ----------------------------------------------------------------------------
@@ -1913,8 +2037,8 @@ func testRoute(t *testing.T, test routeTest) {
 			if err != nil {
 				t.Fatalf("(%v) URLPath error: %v -- %v", test.title, err, getRouteTemplate(route))
 			}
-			if uri.Path != u.Path {
-				t.Errorf("(%v) URLPath not equal: expected %v, got %v -- %v", test.title, uri.Path, u.Path, getRouteTemplate(route))
+			if test.path != u.EscapedPath() {
+				t.Errorf("(%v) URLPath not equal: expected %v, got %v -- %v", test.title, test.path, u.EscapedPath(), getRouteTemplate(route))
 				return
 			}
 		}
@@ -2069,6 +2193,261 @@ func TestSkipClean(t *testing.T) {
 	}
 }
 
+func TestRedirectCodes(t *testing.T) {
+	handler := func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(r.Method + " " + r.URL.RequestURI()))
+	}
+	tests := []struct {
+		name         string
+		configure    func(r *Router)
+		encoded      bool
+		path         string
+		method       string
+		wantCode     int
+		wantLocation string
+		wantBody     string
+	}{
+		{
+			name:         "clean path default",
+			configure:    func(r *Router) {},
+			path:         "/api//users?x=1",
+			method:       "GET",
+			wantCode:     http.StatusMovedPermanently,
+			wantLocation: "http://localhost/api/users?x=1",
+		},
+		{
+			name:         "clean path 308",
+			configure:    func(r *Router) { r.CleanPathRedirect(http.StatusPermanentRedirect) },
+			path:         "/api/./users?x=1",
+			method:       "POST",
+			wantCode:     http.StatusPermanentRedirect,
+			wantLocation: "http://localhost/api/users?x=1",
+		},
+		{
+			name:      "clean path internal",
+			configure: func(r *Router) { r.CleanPathRedirect(InternalRedirect) },
+			path:      "/api//users?x=1",
+			method:    "POST",
+			wantCode:  http.StatusOK,
+			wantBody:  "POST /api/users?x=1",
+		},
+		{
+			name:         "clean path keeps encoded slash",
+			configure:    func(r *Router) {},
+			path:         "/files//a%2Fb?x=1",
+			method:       "GET",
+			wantCode:     http.StatusMovedPermanently,
+			wantLocation: "http://localhost/files/a%2Fb?x=1",
+		},
+		{
+			name:         "clean path keeps encoded slash with encoded path",
+			configure:    func(r *Router) {},
+			encoded:      true,
+			path:         "/files//a%2Fb?x=1",
+			method:       "GET",
+			wantCode:     http.StatusMovedPermanently,
+			wantLocation: "http://localhost/files/a%2Fb?x=1",
+		},
+		{
+			name:         "strict slash default",
+			configure:    func(r *Router) {},
+			path:         "/api/users/?x=1",
+			method:       "GET",
+			wantCode:     http.StatusMovedPermanently,
+			wantLocation: "http://localhost/api/users?x=1",
+		},
+		{
+			name:         "strict slash 307",
+			configure:    func(r *Router) { r.StrictSlashRedirect(http.StatusTemporaryRedirect) },
+			path:         "/api/users/?x=1",
+			method:       "POST",
+			wantCode:     http.StatusTemporaryRedirect,
+			wantLocation: "http://localhost/api/users?x=1",
+		},
+		{
+			name:      "strict slash internal",
+			configure: func(r *Router) { r.StrictSlashRedirect(InternalRedirect) },
+			path:      "/api/users/?x=1",
+			method:    "POST",
+			wantCode:  http.StatusOK,
+			wantBody:  "POST /api/users?x=1",
+		},
+		{
+			name:         "strict slash keeps encoded slash",
+			configure:    func(r *Router) { r.StrictSlashRedirect(http.StatusPermanentRedirect) },
+			encoded:      true,
+			path:         "/files/a%2Fb/",
+			method:       "GET",
+			wantCode:     http.StatusPermanentRedirect,
+			wantLocation: "http://localhost/files/a%2Fb",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			r := NewRouter().StrictSlash(true)
+			if test.encoded {
+				r.UseEncodedPath()
+			}
+			test.configure(r)
+			r.HandleFunc("/api/users", handler)
+			r.HandleFunc("/files/{name}", handler)
+
+			req, _ := http.NewRequest(test.method, "http://localhost"+test.path, nil)
+			res := NewRecorder()
+			r.ServeHTTP(res, req)
+
+			if res.Code != test.wantCode {
+				t.Errorf("Expected status code %d, got %d", test.wantCode, res.Code)
+			}
+			if got := res.Header().Get("Location"); got != test.wantLocation {
+				t.Errorf("Expected location %q, got %q", test.wantLocation, got)
+			}
+			if test.wantBody != "" && res.Body.String() != test.wantBody {
+				t.Errorf("Expected body %q, got %q", test.wantBody, res.Body.String())
+			}
+		})
+	}
+}
+
+func TestRedirectCodeInvalid(t *testing.T) {
+	setters := map[string]func(*Router, int) *Router{
+		"StrictSlashRedirect":   (*Router).StrictSlashRedirect,
+		"CleanPathRedirect":     (*Router).CleanPathRedirect,
+		"CanonicalCaseRedirect": (*Router).CanonicalCaseRedirect,
+	}
+	for name, set := range setters {
+		for _, code := range []int{0, http.StatusOK, http.StatusMultipleChoices, http.StatusNotModified, http.StatusUseProxy, 306, 309, http.StatusNotFound} {
+			func() {
+				defer func() {
+					if recover() == nil {
+						t.Errorf("%s: Expected a panic for redirect status code %d", name, code)
+					}
+				}()
+				set(NewRouter(), code)
+			}()
+		}
+		for _, code := range []int{http.StatusMovedPermanently, http.StatusFound, http.StatusSeeOther, http.StatusTemporaryRedirect, http.StatusPermanentRedirect, InternalRedirect} {
+			func() {
+				defer func() {
+					if err := recover(); err != nil {
+						t.Errorf("%s: Expected no panic for redirect status code %d, got %v", name, code, err)
+					}
+				}()
+				set(NewRouter(), code)
+			}()
+		}
+	}
+}
+
+func TestCaseInsensitivePath(t *testing.T) {
+	handler := func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(r.URL.Path + " " + Vars(r)["name"]))
+	}
+	tests := []struct {
+		name         string
+		redirect     int
+		path         string
+		wantCode     int
+		wantLocation string
+		wantBody     string
+	}{
+		{"exact", 0, "/Users/bob", http.StatusOK, "", "/Users/bob bob"},
+		{"lowercase", 0, "/users/bob", http.StatusOK, "", "/users/bob bob"},
+		{"variable pattern", 0, "/USERS/BOB", http.StatusNotFound, "", ""},
+		{"subrouter", 0, "/API/v1/Items/", http.StatusOK, "", "/API/v1/Items/ "},
+		{"prefix", 0, "/STATIC/css/main.css", http.StatusOK, "", "/STATIC/css/main.css "},
+		{"redirect", http.StatusPermanentRedirect, "/USERS/bob?x=1", http.StatusPermanentRedirect, "http://localhost/Users/bob?x=1", ""},
+		{"redirect exact", http.StatusPermanentRedirect, "/Users/bob", http.StatusOK, "", "/Users/bob bob"},
+		{"redirect subrouter", http.StatusFound, "/api/V1/items/", http.StatusFound, "http://localhost/Api/v1/Items/", ""},
+		{"redirect prefix", http.StatusFound, "/static/css/Main.css", http.StatusFound, "http://localhost/Static/css/Main.css", ""},
+		{"redirect strict slash", http.StatusFound, "/users/bob/", http.StatusFound, "http://localhost/Users/bob", ""},
+		{"strict slash only", http.StatusFound, "/Users/bob/", http.StatusMovedPermanently, "http://localhost/Users/bob", ""},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			r := NewRouter().CaseInsensitivePath(true).StrictSlash(true)
+			if test.redirect != 0 {
+				r.CanonicalCaseRedirect(test.redirect)
+			}
+			r.HandleFunc("/Users/{name:[a-z]+}", handler)
+			r.PathPrefix("/Api").Subrouter().HandleFunc("/v1/Items/", handler)
+			r.PathPrefix("/Static/").HandlerFunc(handler)
+
+			req, _ := http.NewRequest("GET", "http://localhost"+test.path, nil)
+			res := NewRecorder()
+			r.ServeHTTP(res, req)
+
+			if res.Code != test.wantCode {
+				t.Errorf("Expected status code %d, got %d", test.wantCode, res.Code)
+			}
+			if got := res.Header().Get("Location"); got != test.wantLocation {
+				t.Errorf("Expected location %q, got %q", test.wantLocation, got)
+			}
+			if test.wantBody != "" && res.Body.String() != test.wantBody {
+				t.Errorf("Expected body %q, got %q", test.wantBody, res.Body.String())
+			}
+		})
+	}
+
+	r := NewRouter().CaseInsensitivePath(true)
+	r.HandleFunc("/Users/{name}", handler).Name("user")
+	u, err := r.Get("user").URL("name", "bob")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if u.Path != "/Users/bob" {
+		t.Errorf("Expected URL path %q, got %q", "/Users/bob", u.Path)
+	}
+}
+
+func TestOptionalTrailingSlash(t *testing.T) {
+	handler := func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(r.URL.Path))
+	}
+	r := NewRouter().OptionalTrailingSlash(true).StrictSlash(true)
+	r.HandleFunc("/a", handler).Name("a")
+	r.HandleFunc("/b/", handler).Name("b")
+	s := r.PathPrefix("/sub").Subrouter()
+	s.HandleFunc("/{id}/", handler).Name("sub")
+	r.PathPrefix("/prefix/").HandlerFunc(handler)
+
+	tests := []struct {
+		path     string
+		wantCode int
+	}{
+		{"/a", http.StatusOK},
+		{"/a/", http.StatusOK},
+		{"/b", http.StatusOK},
+		{"/b/", http.StatusOK},
+		{"/sub/1", http.StatusOK},
+		{"/sub/1/", http.StatusOK},
+		{"/prefix/x", http.StatusOK},
+		{"/prefix", http.StatusNotFound},
+	}
+	for _, test := range tests {
+		req, _ := http.NewRequest("POST", "http://localhost"+test.path, nil)
+		res := NewRecorder()
+		r.ServeHTTP(res, req)
+		if res.Code != test.wantCode {
+			t.Errorf("%s: Expected status code %d, got %d", test.path, test.wantCode, res.Code)
+		}
+		if test.wantCode == http.StatusOK && res.Body.String() != test.path {
+			t.Errorf("%s: Expected body %q, got %q", test.path, test.path, res.Body.String())
+		}
+	}
+
+	urls := map[string]string{"a": "/a", "b": "/b/", "sub": "/sub/1/"}
+	for name, want := range urls {
+		u, err := r.Get(name).URL("id", "1")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if u.Path != want {
+			t.Errorf("Expected URL path %q, got %q", want, u.Path)
+		}
+	}
+}
+
 func TestStrictQueryParamSep(t *testing.T) {
 	cases := []struct {
 		b     bool
@@ -0,0 +1,285 @@
+// Copyright 2012 The Gorilla Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package muxgen generates typed URL builders for the named routes of a
+// mux.Router.
+//
+// The generated file defines a URLs type wrapping a router, created with
+// New, with one method for each named route, whose parameters are the route
+// variables. For instance, for this route:
+//
+//	r.HandleFunc("/articles/{category}/{id:[0-9]+}", ArticleHandler).
+//	  Name("article")
+//
+// ...the following method is generated:
+//
+//	func (urls *URLs) Article(category string, id int) (*url.URL, error)
+//
+// Variables whose pattern only matches digits, such as [0-9]+ or \d+, are
+// given the int type; other variables are strings. The methods build URLs
+// with mux.Route.URL, from the router given to New:
+//
+//	r := routes.NewRouter()
+//	u := urls.New(r)
+//	articleURL, err := u.Article("technology", 42)
+//
+// The generated file doesn't import the package of the router, so that the
+// handlers registered on the router can use it.
+//
+// Most users run the generator through the muxgen command.
+package muxgen
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"go/token"
+	"io"
+	"regexp"
+	"strings"
+	"text/template"
+	"unicode"
+
+	"github.com/gorilla/mux"
+	"github.com/gorilla/mux/internal/routetpl"
+)
+
+// Config describes the generated file.
+type Config struct {
+	// Package is the name of the package of the generated file.
+	Package string
+}
+
+// Generate writes a Go source file containing one URL builder for each named
+// route of router.
+func Generate(w io.Writer, router *mux.Router, cfg Config) error {
+	if !token.IsIdentifier(cfg.Package) {
+		return fmt.Errorf("muxgen: invalid package name %q", cfg.Package)
+	}
+
+	data := fileData{Config: cfg}
+	funcNames := make(map[string]string)
+	err := router.Walk(func(route *mux.Route, _ *mux.Router, _ []*mux.Route) error {
+		if err := route.GetError(); err != nil {
+			return err
+		}
+		name := route.GetName()
+		if name == "" {
+			return nil
+		}
+		f, err := newFuncData(route)
+		if err != nil {
+			return err
+		}
+		if other, ok := funcNames[f.Func]; ok {
+			return fmt.Errorf("muxgen: routes %q and %q both generate method %s", other, name, f.Func)
+		}
+		funcNames[f.Func] = name
+		for _, p := range f.Params {
+			if p.Type == "int" {
+				data.NeedStrconv = true
+			}
+		}
+		data.Funcs = append(data.Funcs, f)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	var buf bytes.Buffer
+	if err := fileTemplate.Execute(&buf, data); err != nil {
+		return err
+	}
+	src, err := format.Source(buf.Bytes())
+	if err != nil {
+		return fmt.Errorf("muxgen: formatting generated code: %w", err)
+	}
+	_, err = w.Write(src)
+	return err
+}
+
+type fileData struct {
+	Config
+	NeedStrconv bool
+	Funcs       []funcData
+}
+
+type funcData struct {
+	Name   string
+	Func   string
+	Params []paramData
+}
+
+type paramData struct {
+	Var   string
+	Ident string
+	Type  string
+}
+
+// Arg returns the expression converting the parameter to a string.
+func (p paramData) Arg() string {
+	if p.Type == "int" {
+		return "strconv.Itoa(" + p.Ident + ")"
+	}
+	return p.Ident
+}
+
+func newFuncData(route *mux.Route) (funcData, error) {
+	name := route.GetName()
+	f := funcData{Name: name, Func: exportedIdent(name)}
+	if f.Func == "" {
+		return f, fmt.Errorf("muxgen: cannot derive a method name from route %q", name)
+	}
+	vars, err := routeVars(route)
+	if err != nil {
+		return f, fmt.Errorf("muxgen: route %q: %w", name, err)
+	}
+	idents := make(map[string]bool, len(vars))
+	for _, v := range vars {
+		p := paramData{Var: v.Name, Ident: paramIdent(v.Name), Type: "string"}
+		if p.Ident == "" || idents[p.Ident] {
+			return f, fmt.Errorf("muxgen: route %q: cannot derive a parameter name from variable %q", name, v.Name)
+		}
+		idents[p.Ident] = true
+		if intPattern.MatchString(v.Pattern) {
+			p.Type = "int"
+		}
+		f.Params = append(f.Params, p)
+	}
+	return f, nil
+}
+
+// routeVars returns the variables of the route, in the order of
+// mux.Route.GetVarNames.
+func routeVars(route *mux.Route) ([]routetpl.Var, error) {
+	var vars []routetpl.Var
+	add := func(tpl, defaultPattern string) error {
+		vs, err := routetpl.Parse(tpl, defaultPattern)
+		vars = append(vars, vs...)
+		return err
+	}
+	if tpl, err := route.GetHostTemplate(); err == nil {
+		if err := add(tpl, routetpl.DefaultHostPattern); err != nil {
+			return nil, err
+		}
+	}
+	if tpl, err := route.GetPathTemplate(); err == nil {
+		if err := add(tpl, routetpl.DefaultPathPattern); err != nil {
+			return nil, err
+		}
+	}
+	if tpls, err := route.GetQueriesTemplates(); err == nil {
+		for _, tpl := range tpls {
+			_, value := routetpl.SplitQuery(tpl)
+			if err := add(value, routetpl.DefaultQueryPattern); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return vars, nil
+}
+
+// intPattern matches the variable patterns which only match non-negative
+// integers.
+var intPattern = regexp.MustCompile(`^(?:\[0-9\]|\\d)(?:\+|\{[1-9][0-9]*(?:,[0-9]*)?\})$`)
+
+// exportedIdent converts a route name such as "user.list-all" to an exported
+// identifier such as "UserListAll".
+func exportedIdent(name string) string {
+	var b strings.Builder
+	for _, part := range identParts(name) {
+		r := []rune(part)
+		r[0] = unicode.ToUpper(r[0])
+		b.WriteString(string(r))
+	}
+	s := b.String()
+	if s != "" && !unicode.IsLetter([]rune(s)[0]) {
+		s = "Route" + s
+	}
+	return s
+}
+
+// paramIdent converts a variable name such as "item_id" to an unexported
+// identifier such as "itemID" which doesn't shadow a package used by the
+// generated code.
+func paramIdent(name string) string {
+	parts := identParts(name)
+	if len(parts) == 0 {
+		return ""
+	}
+	var b strings.Builder
+	for i, part := range parts {
+		r := []rune(part)
+		if i == 0 {
+			r[0] = unicode.ToLower(r[0])
+		} else if strings.EqualFold(part, "id") || strings.EqualFold(part, "url") {
+			r = []rune(strings.ToUpper(part))
+		} else {
+			r[0] = unicode.ToUpper(r[0])
+		}
+		b.WriteString(string(r))
+	}
+	s := b.String()
+	if !unicode.IsLetter([]rune(s)[0]) {
+		s = "v" + s
+	}
+	if token.IsKeyword(s) || reservedIdents[s] {
+		s += "_"
+	}
+	return s
+}
+
+// reservedIdents are the identifiers used by the generated code.
+var reservedIdents = map[string]bool{
+	"fmt": true, "url": true, "strconv": true, "mux": true,
+	"string": true, "int": true, "error": true, "nil": true,
+	"urls": true,
+}
+
+// identParts splits s on the characters which can't be part of an
+// identifier.
+func identParts(s string) []string {
+	return strings.FieldsFunc(s, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+var fileTemplate = template.Must(template.New("").Parse(`// Code generated by muxgen. DO NOT EDIT.
+
+package {{.Package}}
+
+import (
+	"fmt"
+	"net/url"
+{{- if .NeedStrconv}}
+	"strconv"
+{{- end}}
+
+	"github.com/gorilla/mux"
+)
+
+// URLs builds the URLs of the named routes of a router.
+type URLs struct {
+	router *mux.Router
+}
+
+// New returns the URL builders of the routes of r.
+func New(r *mux.Router) *URLs {
+	return &URLs{router: r}
+}
+
+func (urls *URLs) build(name string, pairs ...string) (*url.URL, error) {
+	route := urls.router.Get(name)
+	if route == nil {
+		return nil, fmt.Errorf("no route named %q", name)
+	}
+	return route.URL(pairs...)
+}
+{{range .Funcs}}
+// {{.Func}} builds a URL for the route {{printf "%q" .Name}}.
+func (urls *URLs) {{.Func}}({{range $i, $p := .Params}}{{if $i}}, {{end}}{{$p.Ident}} {{$p.Type}}{{end}}) (*url.URL, error) {
+	return urls.build({{printf "%q" .Name}}{{range .Params}}, {{printf "%q" .Var}}, {{.Arg}}{{end}})
+}
+{{end}}`))
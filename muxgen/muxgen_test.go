@@ -0,0 +1,82 @@
+package muxgen
+
+import (
+	"bytes"
+	"go/parser"
+	"go/token"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/mux"
+)
+
+func TestGenerate(t *testing.T) {
+	r := mux.NewRouter()
+	r.HandleFunc("/articles/{category}/{id:[0-9]+}", http.NotFound).Name("article")
+	r.HandleFunc("/", http.NotFound)
+	s := r.Host("{sub}.example.com").Subrouter()
+	s.HandleFunc("/users/{user_id:\\d+}", http.NotFound).
+		Queries("url", "{url}").
+		Name("user.show-all")
+
+	var buf bytes.Buffer
+	err := Generate(&buf, r, Config{Package: "urls"})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	src := buf.String()
+
+	if _, err := parser.ParseFile(token.NewFileSet(), "urls.go", src, 0); err != nil {
+		t.Fatalf("Generated code doesn't parse: %v\n%s", err, src)
+	}
+	for _, want := range []string{
+		"package urls",
+		"func New(r *mux.Router) *URLs {",
+		"func (urls *URLs) Article(category string, id int) (*url.URL, error) {",
+		`return urls.build("article", "category", category, "id", strconv.Itoa(id))`,
+		"func (urls *URLs) UserShowAll(sub string, userID int, url_ string) (*url.URL, error) {",
+	} {
+		if !strings.Contains(src, want) {
+			t.Errorf("Expected generated code to contain %q:\n%s", want, src)
+		}
+	}
+	// The generated code only imports the standard library and mux, so that
+	// the package of the router can import it.
+	f, err := parser.ParseFile(token.NewFileSet(), "urls.go", src, parser.ImportsOnly)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, imp := range f.Imports {
+		if path := strings.Trim(imp.Path.Value, `"`); strings.Contains(path, ".") && path != "github.com/gorilla/mux" {
+			t.Errorf("Unexpected import %s", imp.Path.Value)
+		}
+	}
+}
+
+func TestGenerateCollision(t *testing.T) {
+	r := mux.NewRouter()
+	r.Path("/a").Name("user-list")
+	r.Path("/b").Name("user.list")
+
+	err := Generate(new(bytes.Buffer), r, Config{Package: "urls"})
+	if err == nil {
+		t.Error("Expected an error for routes generating the same function")
+	}
+}
+
+func TestIntPattern(t *testing.T) {
+	for pattern, want := range map[string]bool{
+		"[0-9]+":    true,
+		`\d+`:       true,
+		"[0-9]{4}":  true,
+		"[0-9]{1,}": true,
+		"[0-9]*":    false,
+		"[^/]+":     false,
+		"-?[0-9]+":  false,
+	} {
+		if got := intPattern.MatchString(pattern); got != want {
+			t.Errorf("intPattern.MatchString(%q) = %v, want %v", pattern, got, want)
+		}
+	}
+}
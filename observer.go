@@ -0,0 +1,89 @@
+// Copyright 2012 The Gorilla Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package mux
+
+import "net/http"
+
+// RouterObserver is notified of the routing decisions of a router, e.g. to
+// name tracing spans after the matched route templates. See
+// Router.AddObserver.
+//
+// The methods are called by Router.ServeHTTP before the request is handled,
+// with the request carrying the result of the match in its context, so that
+// CurrentRoute, Vars and MatchError can be used. They must not write to the
+// response.
+type RouterObserver interface {
+	// OnMatch is called when a route matches the request.
+	OnMatch(r *http.Request, match *RouteMatch)
+	// OnNotFound is called when no route matches the request.
+	OnNotFound(r *http.Request)
+	// OnMethodNotAllowed is called when routes match the request, but not
+	// its method.
+	OnMethodNotAllowed(r *http.Request)
+	// OnRedirect is called when the request is redirected to a clean path,
+	// or due to StrictSlash.
+	OnRedirect(r *http.Request, location string, code int)
+}
+
+// ObserverFuncs is a RouterObserver calling its non-nil functions.
+type ObserverFuncs struct {
+	Match            func(r *http.Request, match *RouteMatch)
+	NotFound         func(r *http.Request)
+	MethodNotAllowed func(r *http.Request)
+	Redirect         func(r *http.Request, location string, code int)
+}
+
+// OnMatch calls f.Match if not nil.
+func (f ObserverFuncs) OnMatch(r *http.Request, match *RouteMatch) {
+	if f.Match != nil {
+		f.Match(r, match)
+	}
+}
+
+// OnNotFound calls f.NotFound if not nil.
+func (f ObserverFuncs) OnNotFound(r *http.Request) {
+	if f.NotFound != nil {
+		f.NotFound(r)
+	}
+}
+
+// OnMethodNotAllowed calls f.MethodNotAllowed if not nil.
+func (f ObserverFuncs) OnMethodNotAllowed(r *http.Request) {
+	if f.MethodNotAllowed != nil {
+		f.MethodNotAllowed(r)
+	}
+}
+
+// OnRedirect calls f.Redirect if not nil.
+func (f ObserverFuncs) OnRedirect(r *http.Request, location string, code int) {
+	if f.Redirect != nil {
+		f.Redirect(r, location, code)
+	}
+}
+
+// AddObserver registers observers notified of the routing decisions of the
+// router, in the order they are added. Like UseAlways middlewares, observers
+// are only notified by the router serving the request, not by its
+// subrouters.
+func (r *Router) AddObserver(observers ...RouterObserver) *Router {
+	r.observers = append(r.observers, observers...)
+	return r
+}
+
+// notify notifies the observers of the router of the result of a match.
+func (r *Router) notify(req *http.Request, match *RouteMatch) {
+	for _, o := range r.observers {
+		switch {
+		case match.MatchErr == ErrMethodMismatch:
+			o.OnMethodNotAllowed(req)
+		case match.MatchErr != nil || match.Route == nil:
+			o.OnNotFound(req)
+		case match.redirectURL != "":
+			o.OnRedirect(req, match.redirectURL, match.redirectCode)
+		default:
+			o.OnMatch(req, match)
+		}
+	}
+}
@@ -0,0 +1,51 @@
+package mux
+
+import (
+	"fmt"
+	"net/http"
+	"reflect"
+	"testing"
+)
+
+func TestRouterObserver(t *testing.T) {
+	var events []string
+	r := NewRouter()
+	r.HandleFunc("/users/{id}", stringHandler("user")).Methods(http.MethodGet)
+	r.StrictSlash(true).HandleFunc("/strict/", stringHandler("strict"))
+	r.AddObserver(ObserverFuncs{
+		Match: func(req *http.Request, match *RouteMatch) {
+			tpl, _ := match.Route.GetPathTemplate()
+			events = append(events, fmt.Sprintf("match %s %s %v", tpl, Vars(req)["id"], CurrentRoute(req) == match.Route))
+		},
+		NotFound: func(req *http.Request) {
+			events = append(events, "not found "+req.URL.Path)
+		},
+		MethodNotAllowed: func(req *http.Request) {
+			events = append(events, fmt.Sprintf("method not allowed %s %v", req.Method, MatchError(req)))
+		},
+		Redirect: func(req *http.Request, location string, code int) {
+			events = append(events, fmt.Sprintf("redirect %s %d", location, code))
+		},
+	}, ObserverFuncs{})
+
+	for _, req := range []struct{ method, path string }{
+		{http.MethodGet, "/users/1"},
+		{http.MethodPost, "/users/1"},
+		{http.MethodGet, "/missing"},
+		{http.MethodGet, "/a/../users/2"},
+		{http.MethodGet, "/strict"},
+	} {
+		r.ServeHTTP(NewRecorder(), newRequest(req.method, "http://localhost"+req.path))
+	}
+
+	expected := []string{
+		"match /users/{id} 1 true",
+		"method not allowed POST method is not allowed",
+		"not found /missing",
+		"redirect http://localhost/users/2 301",
+		"redirect http://localhost/strict/ 301",
+	}
+	if !reflect.DeepEqual(events, expected) {
+		t.Errorf("Expected events:\n%q\ngot:\n%q", expected, events)
+	}
+}
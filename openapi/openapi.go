@@ -0,0 +1,493 @@
+// Copyright 2012 The Gorilla Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package openapi generates OpenAPI 3.1 documents describing the routes of a
+// mux.Router.
+//
+// Paths, path and query parameters, methods and servers are derived from the
+// route templates and matchers: a route registered with
+//
+//	r.HandleFunc("/users/{id:[0-9]+}", UserHandler).
+//	  Methods("GET").
+//	  Queries("fields", "{fields}").
+//	  Name("user.show")
+//
+// ...is described by a "get" operation of the "/users/{id}" path, with a
+// required "id" path parameter whose pattern is "^[0-9]+$", a required
+// "fields" query parameter, and "user.show" as operation ID. The operation
+// IDs of routes with several methods are suffixed with the method, e.g.
+// "user.show.get" and "user.show.head". Routes which
+// don't restrict methods are described as "get" operations. Routes that
+// only match a path prefix, or without a handler, such as the parent routes
+// of subrouters, are left out.
+//
+// The rest of the description of operations is taken from route metadata,
+// using the keys defined by this package:
+//
+//	r.HandleFunc("/users/{id:[0-9]+}", UserHandler).
+//	  Methods("GET").
+//	  Metadata(openapi.Summary, "Get a user").
+//	  Metadata(openapi.Tags, []string{"users"}).
+//	  Metadata(openapi.ResponseSchema, openapi.Schema{"$ref": "#/components/schemas/User"})
+package openapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strings"
+
+	"github.com/gorilla/mux"
+	"github.com/gorilla/mux/internal/routetpl"
+)
+
+// Version is the version of the OpenAPI specification of the generated
+// documents.
+const Version = "3.1.0"
+
+type metadataKey int
+
+// Route metadata keys describing operations, with the type of their values.
+const (
+	// Summary is the summary of the operation, a string.
+	Summary metadataKey = iota
+	// Description is the description of the operation, a string.
+	Description
+	// Tags are the tags of the operation, a []string.
+	Tags
+	// Deprecated marks the operation as deprecated, a bool.
+	Deprecated
+	// RequestSchema is the schema of the JSON request body, a Schema.
+	RequestSchema
+	// ResponseSchema is the schema of the JSON body of the "200" response,
+	// a Schema.
+	ResponseSchema
+	// Responses are the responses of the operation by status code, a
+	// map[string]*Response. It takes precedence over ResponseSchema for the
+	// codes it defines.
+	Responses
+	// ServerDefaults are the default values of the host variables of the
+	// route by name, a map[string]string. A default value is required for
+	// the variables whose pattern isn't an alternation of literals, such as
+	// "(?:eu|us)", whose first value is the default otherwise.
+	ServerDefaults
+)
+
+// String returns the name of the key.
+func (k metadataKey) String() string {
+	switch k {
+	case Summary:
+		return "Summary"
+	case Description:
+		return "Description"
+	case Tags:
+		return "Tags"
+	case Deprecated:
+		return "Deprecated"
+	case RequestSchema:
+		return "RequestSchema"
+	case ResponseSchema:
+		return "ResponseSchema"
+	case Responses:
+		return "Responses"
+	case ServerDefaults:
+		return "ServerDefaults"
+	}
+	return fmt.Sprintf("metadataKey(%d)", int(k))
+}
+
+// Document is an OpenAPI document.
+type Document struct {
+	OpenAPI string               `json:"openapi"`
+	Info    Info                 `json:"info"`
+	Servers []*Server            `json:"servers,omitempty"`
+	Paths   map[string]*PathItem `json:"paths"`
+}
+
+// Info is the metadata of the API.
+type Info struct {
+	Title       string `json:"title"`
+	Version     string `json:"version"`
+	Description string `json:"description,omitempty"`
+}
+
+// Server is a server of the API.
+type Server struct {
+	URL         string                     `json:"url"`
+	Description string                     `json:"description,omitempty"`
+	Variables   map[string]*ServerVariable `json:"variables,omitempty"`
+}
+
+// ServerVariable is a variable of a server URL.
+type ServerVariable struct {
+	Default     string   `json:"default"`
+	Enum        []string `json:"enum,omitempty"`
+	Description string   `json:"description,omitempty"`
+}
+
+// PathItem describes the operations of a path.
+type PathItem struct {
+	Get     *Operation `json:"get,omitempty"`
+	Put     *Operation `json:"put,omitempty"`
+	Post    *Operation `json:"post,omitempty"`
+	Delete  *Operation `json:"delete,omitempty"`
+	Options *Operation `json:"options,omitempty"`
+	Head    *Operation `json:"head,omitempty"`
+	Patch   *Operation `json:"patch,omitempty"`
+	Trace   *Operation `json:"trace,omitempty"`
+}
+
+// operation returns a pointer to the field of the operation for the given
+// method, or nil if OpenAPI doesn't support the method.
+func (p *PathItem) operation(method string) **Operation {
+	switch method {
+	case http.MethodGet:
+		return &p.Get
+	case http.MethodPut:
+		return &p.Put
+	case http.MethodPost:
+		return &p.Post
+	case http.MethodDelete:
+		return &p.Delete
+	case http.MethodOptions:
+		return &p.Options
+	case http.MethodHead:
+		return &p.Head
+	case http.MethodPatch:
+		return &p.Patch
+	case http.MethodTrace:
+		return &p.Trace
+	}
+	return nil
+}
+
+// Operation describes an API operation on a path.
+type Operation struct {
+	OperationID string               `json:"operationId,omitempty"`
+	Summary     string               `json:"summary,omitempty"`
+	Description string               `json:"description,omitempty"`
+	Tags        []string             `json:"tags,omitempty"`
+	Servers     []*Server            `json:"servers,omitempty"`
+	Parameters  []*Parameter         `json:"parameters,omitempty"`
+	RequestBody *RequestBody         `json:"requestBody,omitempty"`
+	Responses   map[string]*Response `json:"responses,omitempty"`
+	Deprecated  bool                 `json:"deprecated,omitempty"`
+}
+
+// Parameter describes a path or query parameter.
+type Parameter struct {
+	Name        string `json:"name"`
+	In          string `json:"in"`
+	Description string `json:"description,omitempty"`
+	Required    bool   `json:"required"`
+	Schema      Schema `json:"schema,omitempty"`
+}
+
+// RequestBody describes the body of a request.
+type RequestBody struct {
+	Description string                `json:"description,omitempty"`
+	Required    bool                  `json:"required,omitempty"`
+	Content     map[string]*MediaType `json:"content"`
+}
+
+// Response describes a response.
+type Response struct {
+	Description string                `json:"description"`
+	Content     map[string]*MediaType `json:"content,omitempty"`
+}
+
+// MediaType describes the content of a body for a media type.
+type MediaType struct {
+	Schema Schema `json:"schema,omitempty"`
+}
+
+// Schema is a JSON Schema.
+type Schema map[string]any
+
+// Generate returns a document describing the routes of router.
+func Generate(router *mux.Router, info Info) (*Document, error) {
+	doc := &Document{
+		OpenAPI: Version,
+		Info:    info,
+		Paths:   make(map[string]*PathItem),
+	}
+	err := router.Walk(func(route *mux.Route, _ *mux.Router, _ []*mux.Route) error {
+		if err := route.GetError(); err != nil {
+			return err
+		}
+		if h := route.GetHandler(); h == nil {
+			return nil
+		} else if _, ok := h.(*mux.Router); ok {
+			return nil
+		}
+		tpl, err := route.GetPathTemplate()
+		if err != nil {
+			return nil
+		}
+		if route.IsPathPrefix() {
+			return nil
+		}
+		path, err := routetpl.Strip(tpl)
+		if err != nil {
+			return err
+		}
+		item := doc.Paths[path]
+		if item == nil {
+			item = new(PathItem)
+			doc.Paths[path] = item
+		}
+		methods, err := route.GetMethods()
+		if err != nil {
+			methods = []string{http.MethodGet}
+		}
+		for _, m := range methods {
+			// The first matching route wins, as it does when serving.
+			field := item.operation(m)
+			if field == nil || *field != nil {
+				continue
+			}
+			op, err := newOperation(route, tpl)
+			if err != nil {
+				return fmt.Errorf("openapi: route %q: %w", tpl, err)
+			}
+			// Operation IDs must be unique in the document, so the name of a
+			// route with several methods is suffixed with each of them.
+			if op.OperationID != "" && len(methods) > 1 {
+				op.OperationID += "." + strings.ToLower(m)
+			}
+			*field = op
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return doc, nil
+}
+
+// Handler returns a handler serving the document describing the routes of
+// router as JSON. The document is generated on each request, so that it
+// reflects routes registered after the call to Handler.
+func Handler(router *mux.Router, info Info) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		doc, err := Generate(router, info)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		_ = enc.Encode(doc)
+	})
+}
+
+func newOperation(route *mux.Route, pathTpl string) (*Operation, error) {
+	op := &Operation{OperationID: route.GetName()}
+
+	pathVars, err := routetpl.Parse(pathTpl, routetpl.DefaultPathPattern)
+	if err != nil {
+		return nil, err
+	}
+	for _, v := range pathVars {
+		op.Parameters = append(op.Parameters, &Parameter{
+			Name:     v.Name,
+			In:       "path",
+			Required: true,
+			Schema:   stringSchema(v.Pattern, routetpl.DefaultPathPattern),
+		})
+	}
+
+	if queries, err := route.GetQueriesTemplates(); err == nil {
+		for _, q := range queries {
+			key, value := routetpl.SplitQuery(q)
+			schema, err := valueSchema(value, routetpl.DefaultQueryPattern)
+			if err != nil {
+				return nil, err
+			}
+			op.Parameters = append(op.Parameters, &Parameter{
+				Name:     key,
+				In:       "query",
+				Required: true,
+				Schema:   schema,
+			})
+		}
+	}
+
+	if hostTpl, err := route.GetHostTemplate(); err == nil {
+		schemes, _ := route.GetSchemes()
+		var defaults map[string]string
+		if value, err := route.GetMetadataValue(ServerDefaults); err == nil {
+			var ok bool
+			if defaults, ok = value.(map[string]string); !ok {
+				return nil, fmt.Errorf("metadata %v has unexpected type %T", ServerDefaults, value)
+			}
+		}
+		server, err := newServer(hostTpl, schemes, defaults)
+		if err != nil {
+			return nil, err
+		}
+		op.Servers = []*Server{server}
+	}
+
+	if err := applyMetadata(route, op); err != nil {
+		return nil, err
+	}
+	return op, nil
+}
+
+// newServer describes the servers matched by a host template, with the given
+// default values of its variables. The server URL is scheme-relative, unless
+// the route matches a single scheme.
+func newServer(hostTpl string, schemes []string, defaults map[string]string) (*Server, error) {
+	host, err := routetpl.Strip(hostTpl)
+	if err != nil {
+		return nil, err
+	}
+	vars, err := routetpl.Parse(hostTpl, routetpl.DefaultHostPattern)
+	if err != nil {
+		return nil, err
+	}
+	s := &Server{URL: "//" + host}
+	if len(schemes) == 1 {
+		s.URL = schemes[0] + ":" + s.URL
+	}
+	for _, v := range vars {
+		if s.Variables == nil {
+			s.Variables = make(map[string]*ServerVariable, len(vars))
+		}
+		sv := &ServerVariable{Description: "Pattern: " + v.Pattern}
+		if enum := patternEnum(v.Pattern); enum != nil {
+			sv.Enum = enum
+			sv.Default = enum[0]
+		}
+		if d, ok := defaults[v.Name]; ok {
+			sv.Default = d
+		}
+		// OpenAPI requires a default value for every server variable.
+		if sv.Default == "" {
+			return nil, fmt.Errorf("host variable %q has no default value, see ServerDefaults", v.Name)
+		}
+		s.Variables[v.Name] = sv
+	}
+	return s, nil
+}
+
+func applyMetadata(route *mux.Route, op *Operation) error {
+	var responseSchema Schema
+	var requestSchema Schema
+	var responses map[string]*Response
+	for _, m := range []struct {
+		key metadataKey
+		dst any
+	}{
+		{Summary, &op.Summary},
+		{Description, &op.Description},
+		{Tags, &op.Tags},
+		{Deprecated, &op.Deprecated},
+		{RequestSchema, &requestSchema},
+		{ResponseSchema, &responseSchema},
+		{Responses, &responses},
+	} {
+		if err := metadataValue(route, m.key, m.dst); err != nil {
+			return err
+		}
+	}
+	if requestSchema != nil {
+		op.RequestBody = &RequestBody{
+			Required: true,
+			Content:  map[string]*MediaType{"application/json": {Schema: requestSchema}},
+		}
+	}
+	if responseSchema != nil {
+		op.Responses = map[string]*Response{
+			"200": {
+				Description: "OK",
+				Content:     map[string]*MediaType{"application/json": {Schema: responseSchema}},
+			},
+		}
+	}
+	for code, resp := range responses {
+		if op.Responses == nil {
+			op.Responses = make(map[string]*Response, len(responses))
+		}
+		op.Responses[code] = resp
+	}
+	return nil
+}
+
+// metadataValue stores the metadata value of route for key in the variable
+// pointed to by dst, if the route has one.
+func metadataValue(route *mux.Route, key metadataKey, dst any) error {
+	value, err := route.GetMetadataValue(key)
+	if err != nil {
+		return nil
+	}
+	var ok bool
+	switch dst := dst.(type) {
+	case *string:
+		*dst, ok = value.(string)
+	case *[]string:
+		*dst, ok = value.([]string)
+	case *bool:
+		*dst, ok = value.(bool)
+	case *Schema:
+		*dst, ok = value.(Schema)
+	case *map[string]*Response:
+		*dst, ok = value.(map[string]*Response)
+	}
+	if !ok {
+		return fmt.Errorf("metadata %v has unexpected type %T", key, value)
+	}
+	return nil
+}
+
+// valueSchema returns the schema of the strings matching a template, such as
+// the value template of a query.
+func valueSchema(tpl, defaultPattern string) (Schema, error) {
+	vars, err := routetpl.Parse(tpl, defaultPattern)
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case len(vars) == 0 && tpl == "":
+		return Schema{"type": "string"}, nil
+	case len(vars) == 0:
+		return Schema{"type": "string", "enum": []string{tpl}}, nil
+	case len(vars) == 1 && (tpl == "{"+vars[0].Name+"}" || tpl == "{"+vars[0].Name+":"+vars[0].Pattern+"}"):
+		return stringSchema(vars[0].Pattern, defaultPattern), nil
+	}
+	pattern, err := routetpl.Regexp(tpl, defaultPattern)
+	if err != nil {
+		return nil, err
+	}
+	return Schema{"type": "string", "pattern": pattern}, nil
+}
+
+// stringSchema returns the schema of the strings matching a variable
+// pattern. Default patterns are left out.
+func stringSchema(pattern, defaultPattern string) Schema {
+	s := Schema{"type": "string"}
+	if pattern != defaultPattern {
+		s["pattern"] = "^(?:" + pattern + ")$"
+	}
+	return s
+}
+
+var enumPattern = regexp.MustCompile(`^\(\?:([A-Za-z0-9_-]+(?:\|[A-Za-z0-9_-]+)*)\)$|^([A-Za-z0-9_-]+(?:\|[A-Za-z0-9_-]+)+)$`)
+
+// patternEnum returns the values of patterns which are an alternation of
+// literals, such as "(?:eu|us)", or nil.
+func patternEnum(pattern string) []string {
+	m := enumPattern.FindStringSubmatch(pattern)
+	if m == nil {
+		return nil
+	}
+	alternation := m[1]
+	if alternation == "" {
+		alternation = m[2]
+	}
+	return strings.Split(alternation, "|")
+}
@@ -0,0 +1,181 @@
+package openapi
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"regexp"
+	"testing"
+
+	"github.com/gorilla/mux"
+)
+
+func TestGenerate(t *testing.T) {
+	r := mux.NewRouter()
+	r.HandleFunc("/users/{id:[0-9]+}", http.NotFound).
+		Methods("GET", "HEAD").
+		Queries("fields", "{fields}", "format", "json").
+		Name("user.show").
+		Metadata(Summary, "Get a user").
+		Metadata(Tags, []string{"users"}).
+		Metadata(ResponseSchema, Schema{"$ref": "#/components/schemas/User"})
+	r.HandleFunc("/users/{id:[0-9]+}", http.NotFound).
+		Methods("PUT").
+		Metadata(RequestSchema, Schema{"$ref": "#/components/schemas/User"}).
+		Metadata(Responses, map[string]*Response{"204": {Description: "Updated"}})
+	r.PathPrefix("/static/").Handler(http.NotFoundHandler())
+	s := r.Host("{region:(?:eu|us)}.example.com").PathPrefix("/admin").Subrouter()
+	s.HandleFunc("/stats", http.NotFound).Metadata(Deprecated, true)
+
+	doc, err := Generate(r, Info{Title: "Test", Version: "1.0"})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if len(doc.Paths) != 2 {
+		t.Fatalf("Expected 2 paths, got %v", doc.Paths)
+	}
+
+	user := doc.Paths["/users/{id}"]
+	if user == nil || user.Get == nil || user.Head == nil || user.Put == nil || user.Post != nil {
+		t.Fatalf("Unexpected operations for /users/{id}: %+v", user)
+	}
+	expectedGet := &Operation{
+		OperationID: "user.show.get",
+		Summary:     "Get a user",
+		Tags:        []string{"users"},
+		Parameters: []*Parameter{
+			{Name: "id", In: "path", Required: true, Schema: Schema{"type": "string", "pattern": "^(?:[0-9]+)$"}},
+			{Name: "fields", In: "query", Required: true, Schema: Schema{"type": "string"}},
+			{Name: "format", In: "query", Required: true, Schema: Schema{"type": "string", "enum": []string{"json"}}},
+		},
+		Responses: map[string]*Response{
+			"200": {
+				Description: "OK",
+				Content:     map[string]*MediaType{"application/json": {Schema: Schema{"$ref": "#/components/schemas/User"}}},
+			},
+		},
+	}
+	if !reflect.DeepEqual(user.Get, expectedGet) {
+		t.Errorf("Expected %+v, got %+v", expectedGet, user.Get)
+	}
+	expectedHead := *expectedGet
+	expectedHead.OperationID = "user.show.head"
+	if !reflect.DeepEqual(user.Head, &expectedHead) {
+		t.Errorf("Expected %+v, got %+v", &expectedHead, user.Head)
+	}
+	if user.Head.Parameters[0] == user.Get.Parameters[0] {
+		t.Errorf("Expected the operations of a route not to share their parameters")
+	}
+	if user.Put.RequestBody == nil || user.Put.Responses["204"] == nil {
+		t.Errorf("Expected request body and 204 response, got %+v", user.Put)
+	}
+
+	stats := doc.Paths["/admin/stats"]
+	if stats == nil || stats.Get == nil || !stats.Get.Deprecated {
+		t.Fatalf("Unexpected operations for /admin/stats: %+v", stats)
+	}
+	expectedServers := []*Server{{
+		URL: "//{region}.example.com",
+		Variables: map[string]*ServerVariable{
+			"region": {Default: "eu", Enum: []string{"eu", "us"}, Description: "Pattern: (?:eu|us)"},
+		},
+	}}
+	if !reflect.DeepEqual(stats.Get.Servers, expectedServers) {
+		t.Errorf("Expected servers %+v, got %+v", expectedServers[0], stats.Get.Servers[0])
+	}
+}
+
+func TestGenerateSchemes(t *testing.T) {
+	r := mux.NewRouter()
+	r.Host("api.example.com").Schemes("https").Path("/status").HandlerFunc(http.NotFound)
+
+	doc, err := Generate(r, Info{})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	op := doc.Paths["/status"].Get
+	if op == nil || len(op.Servers) != 1 {
+		t.Fatalf("Unexpected operation: %+v", op)
+	}
+	if expected := "https://api.example.com"; op.Servers[0].URL != expected {
+		t.Errorf("Expected server %q, got %q", expected, op.Servers[0].URL)
+	}
+}
+
+func TestGeneratePatternAlternation(t *testing.T) {
+	r := mux.NewRouter()
+	r.HandleFunc("/items/{kind:foo|bar}", http.NotFound)
+
+	doc, err := Generate(r, Info{})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	op := doc.Paths["/items/{kind}"].Get
+	pattern, _ := op.Parameters[0].Schema["pattern"].(string)
+	if expected := "^(?:foo|bar)$"; pattern != expected {
+		t.Fatalf("Expected pattern %q, got %q", expected, pattern)
+	}
+	re := regexp.MustCompile(pattern)
+	for value, want := range map[string]bool{"foo": true, "bar": true, "foox": false, "xbar": false} {
+		if got := re.MatchString(value); got != want {
+			t.Errorf("Expected %q to match: %v, got %v", value, want, got)
+		}
+	}
+}
+
+func TestGenerateServerDefaults(t *testing.T) {
+	r := mux.NewRouter()
+	r.Host("{tenant}.example.com").Path("/status").HandlerFunc(http.NotFound)
+	if _, err := Generate(r, Info{}); err == nil {
+		t.Error("Expected an error for a host variable without default value")
+	}
+
+	r = mux.NewRouter()
+	s := r.Host("{tenant}.{region:(?:eu|us)}.example.com").Subrouter().
+		Metadata(ServerDefaults, map[string]string{"tenant": "demo"})
+	s.Path("/status").HandlerFunc(http.NotFound)
+	doc, err := Generate(r, Info{})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	vars := doc.Paths["/status"].Get.Servers[0].Variables
+	if got := vars["tenant"].Default; got != "demo" {
+		t.Errorf("Expected default %q, got %q", "demo", got)
+	}
+	if got := vars["region"].Default; got != "eu" {
+		t.Errorf("Expected default %q, got %q", "eu", got)
+	}
+}
+
+func TestGenerateInvalidMetadata(t *testing.T) {
+	r := mux.NewRouter()
+	r.HandleFunc("/", http.NotFound).Metadata(Tags, "users")
+
+	if _, err := Generate(r, Info{}); err == nil {
+		t.Error("Expected an error for metadata of the wrong type")
+	}
+}
+
+func TestHandler(t *testing.T) {
+	r := mux.NewRouter()
+	r.Handle("/openapi.json", Handler(r, Info{Title: "Test", Version: "1.0"}))
+
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest("GET", "/openapi.json", nil))
+
+	if ct := rec.Header().Get("Content-Type"); ct != "application/json" {
+		t.Errorf("Expected JSON content type, got %q", ct)
+	}
+	var doc map[string]any
+	if err := json.Unmarshal(rec.Body.Bytes(), &doc); err != nil {
+		t.Fatalf("Invalid JSON: %v", err)
+	}
+	if doc["openapi"] != Version {
+		t.Errorf("Expected version %q, got %v", Version, doc["openapi"])
+	}
+	if _, ok := doc["paths"].(map[string]any)["/openapi.json"]; !ok {
+		t.Errorf("Expected the document to describe itself, got %v", doc["paths"])
+	}
+}
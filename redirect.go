@@ -0,0 +1,91 @@
+// Copyright 2012 The Gorilla Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package mux
+
+import (
+	"net/http"
+)
+
+// Redirect registers a new route with a matcher for the URL path tpl,
+// redirecting the requests it matches to the URL of the route named target,
+// with the given status code. This keeps the old URL of a moved endpoint
+// alive:
+//
+//	r.HandleFunc("/users/{id}", UserHandler).Name("user")
+//	r.Redirect("/members/{id}", "user", http.StatusPermanentRedirect)
+//
+// The URL is built with Route.URL from the variables of the request, which
+// can be renamed with pairs of old and new names, e.g. "member", "id". The
+// query parameters of the request are added to the URL, except those already
+// set by the queries of the target.
+//
+// The target is looked up with Router.Get when a request is served, so it
+// can be registered after the redirect. If it doesn't exist or its URL can't
+// be built, the request is answered with 500 Internal Server Error.
+//
+// It panics if code is not 301, 302, 303, 307 or 308.
+func (r *Router) Redirect(tpl, target string, code int, pairs ...string) *Route {
+	if err := validRedirectCode(code); err != nil {
+		panic(err)
+	}
+	route := r.NewRoute().Path(tpl)
+	route.redirectTarget = target
+	rename, err := mapFromPairsToString(pairs...)
+	if err != nil {
+		route.err = err
+		return route
+	}
+	return route.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		location, ok := r.redirectLocation(req, target, rename)
+		if !ok {
+			http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Location", location)
+		w.WriteHeader(code)
+	})
+}
+
+// redirectLocation builds the URL of the route named target from the
+// variables of req, renamed by rename, with the query parameters of req that
+// the target doesn't set.
+func (r *Router) redirectLocation(req *http.Request, target string, rename map[string]string) (string, bool) {
+	route := r.Get(target)
+	if route == nil {
+		return "", false
+	}
+	vars := Vars(req)
+	pairs := make([]string, 0, 2*len(vars))
+	for k, v := range vars {
+		if name, ok := rename[k]; ok {
+			k = name
+		}
+		pairs = append(pairs, k, v)
+	}
+	u, err := route.URL(pairs...)
+	if err != nil {
+		return "", false
+	}
+	switch {
+	case req.URL.RawQuery == "":
+	case u.RawQuery == "":
+		u.RawQuery = req.URL.RawQuery
+	default:
+		query := u.Query()
+		for k, v := range req.URL.Query() {
+			if _, ok := query[k]; !ok {
+				query[k] = v
+			}
+		}
+		u.RawQuery = query.Encode()
+	}
+	return u.String(), true
+}
+
+// GetRedirectTarget returns the name of the route targeted by a route
+// registered with Router.Redirect, or an empty string if it isn't one.
+func (r *Route) GetRedirectTarget() string {
+	return r.redirectTarget
+}
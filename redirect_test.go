@@ -0,0 +1,112 @@
+// Copyright 2012 The Gorilla Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package mux
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestRedirect(t *testing.T) {
+	r := NewRouter()
+	r.HandleFunc("/users/{id:[0-9]+}", func(w http.ResponseWriter, r *http.Request) {}).Name("user")
+	r.Redirect("/members/{member}", "user", http.StatusPermanentRedirect, "member", "id")
+	r.Redirect("/people/{id}", "user", http.StatusFound).Methods("GET")
+	r.Redirect("/later/{id}", "later", http.StatusMovedPermanently)
+	r.Redirect("/missing", "user", http.StatusMovedPermanently)
+	r.Host("{sub}.example.com").Path("/pages/{page}").Name("page")
+	r.Redirect("/old/{sub}/{page}", "page", http.StatusMovedPermanently)
+	r.HandleFunc("/later/{id}", func(w http.ResponseWriter, r *http.Request) {}).Name("later")
+
+	tests := []struct {
+		method       string
+		url          string
+		wantCode     int
+		wantLocation string
+	}{
+		{"POST", "http://localhost/members/42?x=1&y=2", http.StatusPermanentRedirect, "/users/42?x=1&y=2"},
+		{"GET", "http://localhost/people/42", http.StatusFound, "/users/42"},
+		{"POST", "http://localhost/people/42", http.StatusMethodNotAllowed, ""},
+		{"GET", "http://localhost/people/abc", http.StatusInternalServerError, ""},
+		{"GET", "http://localhost/missing", http.StatusInternalServerError, ""},
+		{"GET", "http://localhost/old/docs/intro?lang=en", http.StatusMovedPermanently, "http://docs.example.com/pages/intro?lang=en"},
+	}
+	for _, test := range tests {
+		req, _ := http.NewRequest(test.method, test.url, nil)
+		res := NewRecorder()
+		r.ServeHTTP(res, req)
+		if res.Code != test.wantCode {
+			t.Errorf("%s %s: Expected status code %d, got %d", test.method, test.url, test.wantCode, res.Code)
+		}
+		if got := res.Header().Get("Location"); got != test.wantLocation {
+			t.Errorf("%s %s: Expected location %q, got %q", test.method, test.url, test.wantLocation, got)
+		}
+	}
+}
+
+func TestRedirectQueries(t *testing.T) {
+	r := NewRouter()
+	r.HandleFunc("/search", func(w http.ResponseWriter, r *http.Request) {}).Queries("v", "2", "q", "{q}").Name("search")
+	r.Redirect("/find/{q}", "search", http.StatusFound)
+
+	req, _ := http.NewRequest("GET", "http://localhost/find/mux?v=1&q=go&page=3&v=0", nil)
+	res := NewRecorder()
+	r.ServeHTTP(res, req)
+	if got, want := res.Header().Get("Location"), "/search?page=3&q=mux&v=2"; got != want {
+		t.Errorf("Expected location %q, got %q", want, got)
+	}
+}
+
+func TestRedirectLateTarget(t *testing.T) {
+	r := NewRouter()
+	r.Redirect("/old/{id}", "new", http.StatusMovedPermanently)
+	r.HandleFunc("/new/{id}", func(w http.ResponseWriter, r *http.Request) {}).Name("new")
+
+	req, _ := http.NewRequest("GET", "http://localhost/old/1", nil)
+	res := NewRecorder()
+	r.ServeHTTP(res, req)
+	if got := res.Header().Get("Location"); got != "/new/1" {
+		t.Errorf("Expected location %q, got %q", "/new/1", got)
+	}
+}
+
+func TestRedirectErrors(t *testing.T) {
+	r := NewRouter()
+	for _, code := range []int{http.StatusOK, http.StatusMultipleChoices, http.StatusNotModified, http.StatusUseProxy, 306, InternalRedirect} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("Expected a panic for redirect status code %d", code)
+				}
+			}()
+			r.Redirect("/a", "b", code)
+		}()
+	}
+	if err := r.Redirect("/a", "b", http.StatusFound, "odd").GetError(); err == nil {
+		t.Errorf("Expected an error for an odd number of rename pairs")
+	}
+}
+
+func TestRedirectRoutes(t *testing.T) {
+	r := NewRouter()
+	r.HandleFunc("/users/{id}", func(w http.ResponseWriter, r *http.Request) {}).Name("user")
+	r.Redirect("/members/{id}", "user", http.StatusPermanentRedirect)
+
+	var targets []string
+	_ = r.Walk(func(route *Route, router *Router, ancestors []*Route) error {
+		tpl, _ := route.GetPathTemplate()
+		targets = append(targets, tpl+" "+route.GetRedirectTarget())
+		return nil
+	})
+	want := []string{"/users/{id} ", "/members/{id} user"}
+	if len(targets) != len(want) || targets[0] != want[0] || targets[1] != want[1] {
+		t.Errorf("Expected %q, got %q", want, targets)
+	}
+
+	routes := r.Routes()
+	if got := routes[1].RedirectTarget; got != "user" {
+		t.Errorf("Expected redirect target %q, got %q", "user", got)
+	}
+}
@@ -14,9 +14,13 @@ import (
 )
 
 type routeRegexpOptions struct {
-	strictSlash         bool
-	useEncodedPath      bool
-	strictQueryParamSep bool
+	strictSlash           bool
+	strictSlashCode       int
+	caseInsensitive       bool
+	caseRedirectCode      int
+	optionalTrailingSlash bool
+	useEncodedPath        bool
+	strictQueryParamSep   bool
 }
 
 type regexpType int
@@ -56,15 +60,24 @@ func newRouteRegexp(tpl string, typ regexpType, options routeRegexpOptions) (*ro
 	// Only match strict slash if not matching
 	if typ != regexpTypePath {
 		options.strictSlash = false
+		options.optionalTrailingSlash = false
 	}
+	if options.optionalTrailingSlash {
+		options.strictSlash = false
+	}
+	if typ != regexpTypePath && typ != regexpTypePrefix {
+		options.caseInsensitive = false
+	}
+	optionalSlash := options.strictSlash || options.optionalTrailingSlash
 	// Set a flag for strictSlash.
 	endSlash := false
-	if options.strictSlash && strings.HasSuffix(tpl, "/") {
+	if optionalSlash && strings.HasSuffix(tpl, "/") {
 		tpl = tpl[:len(tpl)-1]
 		endSlash = true
 	}
 	varsN := make([]string, len(idxs)/2)
 	varsR := make([]*regexp.Regexp, len(idxs)/2)
+	literals := make([]string, 0, len(idxs)/2+1)
 
 	var pattern, reverse strings.Builder
 	pattern.WriteByte('^')
@@ -99,7 +112,8 @@ func newRouteRegexp(tpl string, typ regexpType, options routeRegexpOptions) (*ro
 		// Build the regexp pattern.
 		groupName := varGroupName(groupIdx)
 
-		pattern.WriteString(regexp.QuoteMeta(raw) + "(?P<" + groupName + ">" + patt + ")")
+		pattern.WriteString(quoteLiteral(raw, options.caseInsensitive) + "(?P<" + groupName + ">" + patt + ")")
+		literals = append(literals, raw)
 
 		// Build the reverse template.
 		reverse.WriteString(raw + "%s")
@@ -113,8 +127,9 @@ func newRouteRegexp(tpl string, typ regexpType, options routeRegexpOptions) (*ro
 	}
 	// Add the remaining.
 	raw := tpl[end:]
-	pattern.WriteString(regexp.QuoteMeta(raw))
-	if options.strictSlash {
+	pattern.WriteString(quoteLiteral(raw, options.caseInsensitive))
+	literals = append(literals, raw)
+	if optionalSlash {
 		pattern.WriteString("[/]?")
 	}
 	if typ == regexpTypeQuery {
@@ -160,10 +175,20 @@ func newRouteRegexp(tpl string, typ regexpType, options routeRegexpOptions) (*ro
 		reverse:          reverse.String(),
 		varsN:            varsN,
 		varsR:            varsR,
+		literals:         literals,
 		wildcardHostPort: wildcardHostPort,
 	}, nil
 }
 
+// quoteLiteral returns a regexp matching the fixed part s of a template,
+// regardless of case if caseInsensitive is true.
+func quoteLiteral(s string, caseInsensitive bool) string {
+	if !caseInsensitive || s == "" {
+		return regexp.QuoteMeta(s)
+	}
+	return "(?i:" + regexp.QuoteMeta(s) + ")"
+}
+
 // routeRegexp stores a regexp to match a host or path and information to
 // collect and validate route variables.
 type routeRegexp struct {
@@ -181,6 +206,8 @@ type routeRegexp struct {
 	varsN []string
 	// Variable regexps (validators).
 	varsR []*regexp.Regexp
+	// Fixed parts of the template, around the variables.
+	literals []string
 	// Wildcard host-port (no strict port match in hostname)
 	wildcardHostPort bool
 }
@@ -209,34 +236,113 @@ func (r *routeRegexp) Match(req *http.Request, match *RouteMatch) bool {
 }
 
 // url builds a URL part using the given values.
+//
+// The returned string is in the form that is matched against requests: for
+// a host, labels are converted to their ASCII (punycode) form; for a path,
+// values are percent-encoded if the route matches the encoded path, and kept
+// unescaped otherwise; for a query, values are escaped. Variables are
+// validated against their patterns in the matched form.
 func (r *routeRegexp) url(values map[string]string) (string, error) {
 	urlValues := make([]interface{}, len(r.varsN))
+	matchValues := make([]interface{}, len(r.varsN))
 	for k, v := range r.varsN {
 		value, ok := values[v]
 		if !ok {
 			return "", fmt.Errorf("mux: missing route variable %q", v)
 		}
-		if r.regexpType == regexpTypeQuery {
+		matchValue := value
+		switch r.regexpType {
+		case regexpTypeQuery:
 			value = url.QueryEscape(value)
+		case regexpTypeHost:
+			// The whole host is converted below, as a label may span a
+			// variable and the surrounding template.
+			asciiValue, err := hostToASCII(value)
+			if err != nil {
+				return "", err
+			}
+			matchValue = asciiValue
+		case regexpTypePath, regexpTypePrefix:
+			if r.options.useEncodedPath {
+				value = escapePathSegment(value)
+				matchValue = value
+			}
 		}
 		urlValues[k] = value
+		matchValues[k] = matchValue
 	}
 	rv := fmt.Sprintf(r.reverse, urlValues...)
-	if !r.regexp.MatchString(rv) {
+	mv := rv
+	switch r.regexpType {
+	case regexpTypeQuery:
+		mv = fmt.Sprintf(r.reverse, matchValues...)
+	case regexpTypeHost:
+		var err error
+		if rv, err = hostToASCII(rv); err != nil {
+			return "", err
+		}
+		mv = rv
+	}
+	if !r.regexp.MatchString(mv) {
 		// The URL is checked against the full regexp, instead of checking
 		// individual variables. This is faster but to provide a good error
 		// message, we check individual regexps if the URL doesn't match.
 		for k, v := range r.varsN {
-			if !r.varsR[k].MatchString(values[v]) {
+			if !r.varsR[k].MatchString(matchValues[k].(string)) {
 				return "", fmt.Errorf(
 					"mux: variable %q doesn't match, expected %q", values[v],
 					r.varsR[k].String())
 			}
 		}
+		// The variables match, but not the whole, e.g. once converted to
+		// ASCII.
+		return "", fmt.Errorf("mux: built URL part %q doesn't match %q", mv, r.regexp.String())
 	}
 	return rv, nil
 }
 
+// setURLPath sets the path of u from a path built by url, which is escaped
+// if the route matches the encoded path. RawPath is only set when the
+// escaped form differs from the default encoding of the path, e.g. when it
+// contains an encoded slash.
+func (r *routeRegexp) setURLPath(u *url.URL, p string) error {
+	if !r.options.useEncodedPath {
+		u.Path = p
+		return nil
+	}
+	unescaped, err := url.PathUnescape(p)
+	if err != nil {
+		return fmt.Errorf("mux: invalid escaped path %q: %w", p, err)
+	}
+	u.Path = unescaped
+	if (&url.URL{Path: unescaped}).EscapedPath() != p {
+		u.RawPath = p
+	}
+	return nil
+}
+
+// escapePathSegment escapes s so that it can be used as a single path
+// segment. Valid percent-encoded sequences are kept as they are, so that
+// the values returned by Vars for routes matching the encoded path can be
+// used to build URLs.
+func escapePathSegment(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if c == '%' && i+2 < len(s) && ishex(s[i+1]) && ishex(s[i+2]) {
+			b.WriteString(s[i : i+3])
+			i += 2
+			continue
+		}
+		b.WriteString(url.PathEscape(s[i : i+1]))
+	}
+	return b.String()
+}
+
+func ishex(c byte) bool {
+	return '0' <= c && c <= '9' || 'a' <= c && c <= 'f' || 'A' <= c && c <= 'F'
+}
+
 // getURLQuery returns a single query parameter from a request URL.
 // For a URL with foo=bar&baz=ding, we return only the relevant key
 // value pair for the routeRegexp.
@@ -365,20 +471,40 @@ func (v routeRegexpGroup) setMatch(req *http.Request, m *RouteMatch, r *Route) {
 			}
 		}
 		// Check if we should redirect.
+		p, escaped, code := req.URL.EscapedPath(), true, 0
+		// The case of the paths matched by the routes of subrouters is
+		// checked by their own routes, with their full templates.
+		if c := v.path.options.caseRedirectCode; c > 0 && m.Route == r {
+			if cp := v.path.canonicalPath(path); cp != path {
+				p, escaped, code = cp, r.useEncodedPath, c
+			}
+		}
+		internal := false
 		if v.path.options.strictSlash {
 			p1 := strings.HasSuffix(path, "/")
 			p2 := strings.HasSuffix(v.path.template, "/")
 			if p1 != p2 {
-				p := req.URL.Path
 				if p1 {
 					p = p[:len(p)-1]
 				} else {
 					p += "/"
 				}
-				u := replaceURLPath(req.URL, p)
-				m.Handler = http.RedirectHandler(u, http.StatusMovedPermanently)
+				// Both fixes are made by a single redirect, with the code
+				// of the case redirect, see Router.CanonicalCaseRedirect.
+				if c := redirectCode(v.path.options.strictSlashCode); c == InternalRedirect {
+					internal = true
+				} else if code == 0 {
+					code = c
+				}
 			}
 		}
+		if code != 0 {
+			u := replaceURLPath(req.URL, p, escaped)
+			m.Handler = http.RedirectHandler(u, code)
+			m.redirectURL, m.redirectCode = u, code
+		} else if internal {
+			m.internalPath = p
+		}
 	}
 	// Store query string variables.
 	for _, q := range v.queries {
@@ -392,6 +518,33 @@ func (v routeRegexpGroup) setMatch(req *http.Request, m *RouteMatch, r *Route) {
 	}
 }
 
+// canonicalPath returns path, matched by r, with the fixed parts of the
+// template instead of the parts of path matching them regardless of case.
+func (r *routeRegexp) canonicalPath(path string) string {
+	loc := r.regexp.FindStringSubmatchIndex(path)
+	if loc == nil {
+		return path
+	}
+	var b strings.Builder
+	end := loc[0]
+	for i, lit := range r.literals[:len(r.literals)-1] {
+		b.WriteString(lit)
+		if start := loc[2*i+2]; start >= 0 {
+			end = loc[2*i+3]
+			b.WriteString(path[start:end])
+		}
+	}
+	// The last fixed part may be followed by an optional slash.
+	tail := r.literals[len(r.literals)-1]
+	b.WriteString(tail)
+	if !strings.EqualFold(path[end:loc[1]], tail) {
+		b.WriteByte('/')
+	}
+	// The rest of the path, if r is a prefix.
+	b.WriteString(path[loc[1]:])
+	return b.String()
+}
+
 // getHost tries its best to return the request host.
 // According to section 14.23 of RFC 2616 the Host header
 // can include the port number if the default value of 80 is not used.
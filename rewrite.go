@@ -0,0 +1,208 @@
+// Copyright 2012 The Gorilla Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package mux
+
+import (
+	"context"
+	"mime"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// RewriteFunc rewrites a request before it is matched against the routes of a
+// router. It returns the rewritten request, which should be a copy if it
+// differs from the given one, or nil to leave it unchanged. See
+// Router.Rewrite.
+type RewriteFunc func(*http.Request) *http.Request
+
+// Rewrite appends functions rewriting the requests served by the router
+// before they are matched, e.g. to support legacy path aliases:
+//
+//	r.Rewrite(mux.RewritePath("/index.html", "/"), mux.RewriteStripPrefix("/v1"))
+//
+// The functions run in the order they are added, in ServeHTTP, after the
+// path is cleaned and before the routes are matched, so they get clean paths
+// and the redirects to clean paths use the URL of the request. The URL and
+// method of the request before cleaning and rewriting are available with
+// OriginalURL and OriginalMethod.
+//
+// Like UseAlways middlewares, rewrites are only run by the router serving
+// the request, not by its subrouters.
+func (r *Router) Rewrite(fns ...RewriteFunc) *Router {
+	r.rewrites = append(r.rewrites, fns...)
+	return r
+}
+
+type original struct {
+	url    *url.URL
+	method string
+}
+
+// withOriginal records the URL and method of req in its context, unless they
+// already are.
+func withOriginal(req *http.Request) *http.Request {
+	if req.Context().Value(originalKey) != nil {
+		return req
+	}
+	u := *req.URL
+	ctx := context.WithValue(req.Context(), originalKey, original{url: &u, method: req.Method})
+	return req.WithContext(ctx)
+}
+
+// rewrite runs the rewrites of the router.
+func (r *Router) rewrite(req *http.Request) *http.Request {
+	for _, fn := range r.rewrites {
+		if rewritten := fn(req); rewritten != nil {
+			req = rewritten
+		}
+	}
+	return req
+}
+
+// OriginalURL returns the URL of the request before it was rewritten by the
+// router, see Router.Rewrite, or the URL of the request if it wasn't.
+func OriginalURL(r *http.Request) *url.URL {
+	if rv := r.Context().Value(originalKey); rv != nil {
+		return rv.(original).url
+	}
+	return r.URL
+}
+
+// OriginalMethod returns the method of the request before it was rewritten
+// by the router, see Router.Rewrite, or the method of the request if it
+// wasn't.
+func OriginalMethod(r *http.Request) string {
+	if rv := r.Context().Value(originalKey); rv != nil {
+		return rv.(original).method
+	}
+	return r.Method
+}
+
+// RewritePath returns a RewriteFunc replacing the path from by to, e.g. to
+// keep serving a legacy path.
+func RewritePath(from, to string) RewriteFunc {
+	return func(r *http.Request) *http.Request {
+		if r.URL.Path != from {
+			return nil
+		}
+		return withPath(r, to, "")
+	}
+}
+
+// RewriteStripPrefix returns a RewriteFunc removing prefix from the paths
+// starting with it. The resulting path starts with a slash.
+func RewriteStripPrefix(prefix string) RewriteFunc {
+	prefix = strings.TrimSuffix(prefix, "/")
+	return func(r *http.Request) *http.Request {
+		p := r.URL.Path
+		if !strings.HasPrefix(p, prefix) || (len(p) > len(prefix) && p[len(prefix)] != '/') {
+			return nil
+		}
+		rawPath := r.URL.RawPath
+		if strings.HasPrefix(rawPath, prefix) {
+			rawPath = "/" + strings.TrimPrefix(rawPath[len(prefix):], "/")
+		} else {
+			rawPath = ""
+		}
+		return withPath(r, "/"+strings.TrimPrefix(p[len(prefix):], "/"), rawPath)
+	}
+}
+
+// RewriteLowercase returns a RewriteFunc lowercasing paths. The encoding of
+// a path, e.g. "%2F", is kept unless it has escaped characters which are
+// not lowercased the same way as the decoded path.
+func RewriteLowercase() RewriteFunc {
+	return func(r *http.Request) *http.Request {
+		p := strings.ToLower(r.URL.Path)
+		if p == r.URL.Path {
+			return nil
+		}
+		rawPath := strings.ToLower(r.URL.RawPath)
+		if unescaped, err := url.PathUnescape(rawPath); err != nil || unescaped != p {
+			rawPath = ""
+		}
+		return withPath(r, p, rawPath)
+	}
+}
+
+// withPath returns a shallow copy of r with its path set to p, and its
+// encoded path to rawPath.
+func withPath(r *http.Request, p, rawPath string) *http.Request {
+	r2 := new(http.Request)
+	*r2 = *r
+	u := *r.URL
+	u.Path = p
+	u.RawPath = rawPath
+	r2.URL = &u
+	return r2
+}
+
+// MethodOverrideOptions configures the RewriteFunc returned by
+// MethodOverride.
+type MethodOverrideOptions struct {
+	// Header is the request header holding the overriding method. It
+	// defaults to "X-HTTP-Method-Override", and "-" disables it.
+	Header string
+	// FormField is the form field holding the overriding method, read from
+	// URL-encoded form bodies only, so that multipart bodies are left to the
+	// handlers. It defaults to "_method", and "-" disables it.
+	FormField string
+	// Methods are the methods which can override POST. They default to PUT,
+	// PATCH and DELETE.
+	Methods []string
+}
+
+// MethodOverride returns a RewriteFunc overriding the method of POST
+// requests with the method given in a header or a form field, so that HTML
+// forms can reach routes restricted to other methods:
+//
+//	r.Rewrite(mux.MethodOverride(mux.MethodOverrideOptions{}))
+//	r.HandleFunc("/articles/{id}", DeleteArticle).Methods(http.MethodDelete)
+//
+//	<form method="POST" action="/articles/42">
+//	  <input type="hidden" name="_method" value="DELETE">
+//	</form>
+//
+// The header takes precedence over the form field. Methods which are not
+// allowed are ignored. Handlers can get the original method with
+// OriginalMethod.
+func MethodOverride(opts MethodOverrideOptions) RewriteFunc {
+	if opts.Header == "" {
+		opts.Header = "X-HTTP-Method-Override"
+	}
+	if opts.FormField == "" {
+		opts.FormField = "_method"
+	}
+	if opts.Methods == nil {
+		opts.Methods = []string{http.MethodPut, http.MethodPatch, http.MethodDelete}
+	}
+	return func(r *http.Request) *http.Request {
+		if r.Method != http.MethodPost {
+			return nil
+		}
+		var method string
+		if opts.Header != "-" {
+			method = r.Header.Get(opts.Header)
+		}
+		if method == "" && opts.FormField != "-" && isURLEncodedForm(r) {
+			method = r.PostFormValue(opts.FormField)
+		}
+		method = strings.ToUpper(strings.TrimSpace(method))
+		if method == "" || !containsFold(opts.Methods, method) {
+			return nil
+		}
+		r2 := new(http.Request)
+		*r2 = *r
+		r2.Method = method
+		return r2
+	}
+}
+
+// isURLEncodedForm reports whether the body of r is a URL-encoded form.
+func isURLEncodedForm(r *http.Request) bool {
+	ct, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	return ct == "application/x-www-form-urlencoded"
+}
@@ -0,0 +1,213 @@
+package mux
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestRewrite(t *testing.T) {
+	r := NewRouter().UseEncodedPath()
+	var gotPath, gotOriginal, gotMethod, gotOriginalMethod string
+	handler := func(w http.ResponseWriter, req *http.Request) {
+		gotPath = req.URL.EscapedPath()
+		gotOriginal = OriginalURL(req).EscapedPath()
+		gotMethod = req.Method
+		gotOriginalMethod = OriginalMethod(req)
+		w.WriteHeader(http.StatusOK)
+	}
+	r.HandleFunc("/", handler)
+	r.HandleFunc("/users/{id}", handler)
+	r.HandleFunc("/files/{name}", handler)
+	r.HandleFunc("/purge", handler).Methods("PURGE")
+	r.Rewrite(
+		RewritePath("/index.html", "/"),
+		RewriteStripPrefix("/v1/"),
+		RewriteLowercase(),
+		func(req *http.Request) *http.Request {
+			if req.URL.Path != "/purge" {
+				return nil
+			}
+			r2 := req.Clone(req.Context())
+			r2.Method = "PURGE"
+			return r2
+		},
+	)
+
+	tests := []struct {
+		title              string
+		path               string
+		wantCode           int
+		wantPath           string
+		wantOriginal       string
+		wantMethod         string
+		wantOriginalMethod string
+	}{
+		{"alias", "/index.html", http.StatusOK, "/", "/index.html", "GET", "GET"},
+		{"stripped prefix", "/v1/users/1", http.StatusOK, "/users/1", "/v1/users/1", "GET", "GET"},
+		{"stripped prefix of an encoded path", "/v1/files/a%2Fb", http.StatusOK, "/files/a%2Fb", "/v1/files/a%2Fb", "GET", "GET"},
+		{"prefix of another segment", "/v10/users/1", http.StatusNotFound, "", "", "", ""},
+		{"lowercased path", "/USERS/Bob", http.StatusOK, "/users/bob", "/USERS/Bob", "GET", "GET"},
+		{"lowercased encoded path", "/FILES/A%2FB", http.StatusOK, "/files/a%2fb", "/FILES/A%2FB", "GET", "GET"},
+		{"rewritten method", "/purge", http.StatusOK, "/purge", "/purge", "PURGE", "GET"},
+		{"not rewritten", "/users/2", http.StatusOK, "/users/2", "/users/2", "GET", "GET"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.title, func(t *testing.T) {
+			gotPath, gotOriginal, gotMethod, gotOriginalMethod = "", "", "", ""
+			rw := NewRecorder()
+			r.ServeHTTP(rw, newRequest(http.MethodGet, "http://localhost"+tc.path))
+			if rw.Code != tc.wantCode {
+				t.Errorf("Expected status %d, got %d", tc.wantCode, rw.Code)
+			}
+			if gotPath != tc.wantPath || gotOriginal != tc.wantOriginal {
+				t.Errorf("Expected path %q and original path %q, got %q and %q", tc.wantPath, tc.wantOriginal, gotPath, gotOriginal)
+			}
+			if gotMethod != tc.wantMethod || gotOriginalMethod != tc.wantOriginalMethod {
+				t.Errorf("Expected method %q and original method %q, got %q and %q", tc.wantMethod, tc.wantOriginalMethod, gotMethod, gotOriginalMethod)
+			}
+		})
+	}
+}
+
+func TestRewriteLowercaseEscaped(t *testing.T) {
+	req := newRequest(http.MethodGet, "http://localhost/FILES/%C3%89%2F")
+	req = RewriteLowercase()(req)
+	if req.URL.Path != "/files/é/" || req.URL.RawPath != "" {
+		t.Errorf("Expected path %q without raw path, got %q and %q", "/files/é/", req.URL.Path, req.URL.RawPath)
+	}
+}
+
+func TestRewriteCleanPath(t *testing.T) {
+	r := NewRouter()
+	r.Rewrite(RewriteStripPrefix("/v1"))
+	var gotPath, gotOriginal string
+	r.HandleFunc("/users", func(w http.ResponseWriter, req *http.Request) {
+		gotPath, gotOriginal = req.URL.Path, OriginalURL(req).Path
+		w.WriteHeader(http.StatusOK)
+	})
+
+	rw := NewRecorder()
+	r.ServeHTTP(rw, newRequest(http.MethodGet, "http://localhost/v1//users?x=1"))
+	if rw.Code != http.StatusMovedPermanently {
+		t.Errorf("Expected status %d, got %d", http.StatusMovedPermanently, rw.Code)
+	}
+	if got, want := rw.Header().Get("Location"), "http://localhost/v1/users?x=1"; got != want {
+		t.Errorf("Expected location %q, got %q", want, got)
+	}
+
+	r.CleanPathRedirect(InternalRedirect)
+	rw = NewRecorder()
+	r.ServeHTTP(rw, newRequest(http.MethodGet, "http://localhost/v1//users"))
+	if rw.Code != http.StatusOK {
+		t.Errorf("Expected status %d, got %d", http.StatusOK, rw.Code)
+	}
+	if gotPath != "/users" || gotOriginal != "/v1//users" {
+		t.Errorf("Expected path %q and original path %q, got %q and %q", "/users", "/v1//users", gotPath, gotOriginal)
+	}
+}
+
+func TestOriginalWithoutRewrite(t *testing.T) {
+	req := newRequest(http.MethodPost, "/a")
+	if OriginalURL(req) != req.URL || OriginalMethod(req) != http.MethodPost {
+		t.Error("Expected the URL and method of the request")
+	}
+}
+
+func TestMethodOverride(t *testing.T) {
+	newRouter := func(opts MethodOverrideOptions) (*Router, *string) {
+		var got string
+		r := NewRouter()
+		r.Rewrite(MethodOverride(opts))
+		r.HandleFunc("/articles/{id}", func(w http.ResponseWriter, req *http.Request) {
+			got = req.Method + " " + OriginalMethod(req) + " " + req.PostFormValue("title")
+		}).Methods(http.MethodPost, http.MethodPut, http.MethodDelete, http.MethodPatch, http.MethodGet)
+		return r, &got
+	}
+
+	tests := []struct {
+		title    string
+		opts     MethodOverrideOptions
+		method   string
+		header   map[string]string
+		body     string
+		expected string
+	}{
+		{
+			title:    "form field",
+			method:   http.MethodPost,
+			header:   map[string]string{"Content-Type": "application/x-www-form-urlencoded"},
+			body:     "_method=delete&title=x",
+			expected: "DELETE POST x",
+		},
+		{
+			title:    "header",
+			method:   http.MethodPost,
+			header:   map[string]string{"X-HTTP-Method-Override": "PATCH"},
+			expected: "PATCH POST ",
+		},
+		{
+			title:    "header takes precedence",
+			method:   http.MethodPost,
+			header:   map[string]string{"X-HTTP-Method-Override": "PUT", "Content-Type": "application/x-www-form-urlencoded"},
+			body:     "_method=DELETE",
+			expected: "PUT POST ",
+		},
+		{
+			title:    "method not allowed",
+			method:   http.MethodPost,
+			header:   map[string]string{"X-HTTP-Method-Override": "GET"},
+			expected: "POST POST ",
+		},
+		{
+			title:    "only POST is overridden",
+			method:   http.MethodGet,
+			header:   map[string]string{"X-HTTP-Method-Override": "DELETE"},
+			expected: "GET GET ",
+		},
+		{
+			title:    "form field of a body which isn't a form",
+			method:   http.MethodPost,
+			header:   map[string]string{"Content-Type": "application/json"},
+			body:     "_method=DELETE",
+			expected: "POST POST ",
+		},
+		{
+			title:    "multipart form is not read",
+			method:   http.MethodPost,
+			header:   map[string]string{"Content-Type": "multipart/form-data; boundary=b"},
+			body:     "--b\r\nContent-Disposition: form-data; name=\"_method\"\r\n\r\nDELETE\r\n--b\r\nContent-Disposition: form-data; name=\"title\"\r\n\r\nx\r\n--b--\r\n",
+			expected: "POST POST x",
+		},
+		{
+			title:    "form content type with parameters",
+			method:   http.MethodPost,
+			header:   map[string]string{"Content-Type": "Application/X-WWW-Form-URLEncoded; charset=utf-8"},
+			body:     "_method=PUT",
+			expected: "PUT POST ",
+		},
+		{
+			title:    "custom names",
+			opts:     MethodOverrideOptions{Header: "-", FormField: "verb", Methods: []string{http.MethodGet}},
+			method:   http.MethodPost,
+			header:   map[string]string{"X-HTTP-Method-Override": "DELETE", "Content-Type": "application/x-www-form-urlencoded"},
+			body:     "verb=get",
+			expected: "GET POST ",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.title, func(t *testing.T) {
+			r, got := newRouter(tc.opts)
+			req, _ := http.NewRequest(tc.method, "http://localhost/articles/1", strings.NewReader(tc.body))
+			for k, v := range tc.header {
+				req.Header.Set(k, v)
+			}
+			r.ServeHTTP(NewRecorder(), req)
+			if *got != tc.expected {
+				t.Errorf("Expected %q, got %q", tc.expected, *got)
+			}
+		})
+	}
+}
@@ -21,6 +21,8 @@ type Route struct {
 	buildOnly bool
 	// The name used to build URLs.
 	name string
+	// The name of the route targeted by a redirect, see Router.Redirect.
+	redirectTarget string
 	// Error resulted from building a route.
 	err error
 
@@ -33,6 +35,9 @@ type Route struct {
 	// route specific middleware
 	middlewares []middleware
 
+	// The group which registered the route, if any.
+	group *Router
+
 	// config possibly passed in from `Router`
 	routeConf
 }
@@ -140,20 +145,28 @@ func (r *Route) Metadata(key any, value any) *Route {
 	return r
 }
 
-// GetMetadata returns the metadata map for route
+// GetMetadata returns the metadata map for route, without the metadata
+// inherited from its routers. See GetEffectiveMetadata.
 func (r *Route) GetMetadata() map[any]any {
 	return r.metadata
 }
 
-// MetadataContains returns whether or not the key is present in the metadata map
+// GetEffectiveMetadata returns the metadata of the route merged with the
+// metadata inherited from its routers, the values of the route overriding
+// the inherited ones. See Router.Metadata.
+func (r *Route) GetEffectiveMetadata() map[any]any {
+	return mergeMetadata(r.inheritedMetadata, r.metadata)
+}
+
+// MetadataContains returns whether or not the key is present in the effective metadata map
 func (r *Route) MetadataContains(key any) bool {
-	_, ok := r.metadata[key]
+	_, ok := r.metadataValue(key)
 	return ok
 }
 
-// GetMetadataValue returns the value of a specific key in the metadata map. If the key is not present in the map mux.ErrMetadataKeyNotFound is returned
+// GetMetadataValue returns the value of a specific key in the effective metadata map. If the key is not present in the map mux.ErrMetadataKeyNotFound is returned
 func (r *Route) GetMetadataValue(key any) (any, error) {
-	value, ok := r.metadata[key]
+	value, ok := r.metadataValue(key)
 	if !ok {
 		return nil, ErrMetadataKeyNotFound
 	}
@@ -161,9 +174,9 @@ func (r *Route) GetMetadataValue(key any) (any, error) {
 	return value, nil
 }
 
-// GetMetadataValueOr returns the value of a specific key in the metadata map. If the key is not present in the metadata the fallback value is returned
+// GetMetadataValueOr returns the value of a specific key in the effective metadata map. If the key is not present in the metadata the fallback value is returned
 func (r *Route) GetMetadataValueOr(key any, fallbackValue any) any {
-	value, ok := r.metadata[key]
+	value, ok := r.metadataValue(key)
 	if !ok {
 		return fallbackValue
 	}
@@ -171,6 +184,32 @@ func (r *Route) GetMetadataValueOr(key any, fallbackValue any) any {
 	return value
 }
 
+// metadataValue returns the value of a key in the metadata of the route, or
+// else in its inherited metadata.
+func (r *Route) metadataValue(key any) (any, bool) {
+	if value, ok := r.metadata[key]; ok {
+		return value, true
+	}
+	value, ok := r.inheritedMetadata[key]
+	return value, ok
+}
+
+// mergeMetadata returns a new map with the entries of parent and child, the
+// values of child overriding those of parent, or nil if both are empty.
+func mergeMetadata(parent, child map[any]any) map[any]any {
+	if len(parent) == 0 && len(child) == 0 {
+		return nil
+	}
+	m := make(map[any]any, len(parent)+len(child))
+	for k, v := range parent {
+		m[k] = v
+	}
+	for k, v := range child {
+		m[k] = v
+	}
+	return m
+}
+
 // Handler --------------------------------------------------------------------
 
 // Handler sets a handler for the route.
@@ -208,12 +247,20 @@ func (r *Route) GetHandlerWithMiddlewares() http.Handler {
 // Name -----------------------------------------------------------------------
 
 // Name sets the name for the route, used to build URLs.
-// It is an error to call Name more than once on a route.
+// It is an error to call Name more than once on a route, or to give the same
+// name to two routes.
+//
+// If the route was created by a router with a namespace, the name is
+// prefixed with it. See Router.Namespace.
 func (r *Route) Name(name string) *Route {
 	if r.name != "" {
 		r.err = fmt.Errorf("mux: route already has name %q, can't set %q",
 			r.name, name)
 	}
+	name = qualifiedName(r.namespace, name)
+	if other, ok := r.namedRoutes[name]; ok && other != r && r.err == nil {
+		r.err = fmt.Errorf("mux: route name %q is already registered", name)
+	}
 	if r.err == nil {
 		r.name = name
 		r.namedRoutes[name] = r
@@ -221,7 +268,7 @@ func (r *Route) Name(name string) *Route {
 	return r
 }
 
-// GetName returns the name for the route, if any.
+// GetName returns the fully qualified name for the route, if any.
 func (r *Route) GetName() string {
 	return r.name
 }
@@ -257,9 +304,13 @@ func (r *Route) addRegexpMatcher(tpl string, typ regexpType) error {
 		}
 	}
 	rr, err := newRouteRegexp(tpl, typ, routeRegexpOptions{
-		strictSlash:         r.strictSlash,
-		useEncodedPath:      r.useEncodedPath,
-		strictQueryParamSep: r.strictQueryParamSep,
+		strictSlash:           r.strictSlash,
+		strictSlashCode:       r.strictSlashCode,
+		caseInsensitive:       r.caseInsensitivePath,
+		caseRedirectCode:      r.caseRedirectCode,
+		optionalTrailingSlash: r.optionalTrailingSlash,
+		useEncodedPath:        r.useEncodedPath,
+		strictQueryParamSep:   r.strictQueryParamSep,
 	})
 	if err != nil {
 		return err
@@ -558,6 +609,7 @@ func (r *Route) BuildVarsFunc(f BuildVarsFunc) *Route {
 func (r *Route) Subrouter() *Router {
 	// initialize a subrouter with a copy of the parent route's configuration
 	router := &Router{routeConf: copyRouteConf(r.routeConf), namedRoutes: r.namedRoutes}
+	router.inheritedMetadata = mergeMetadata(r.inheritedMetadata, r.metadata)
 	r.addMatcher(router)
 	return router
 }
@@ -604,6 +656,13 @@ func (r *Route) Subrouter() *Router {
 //
 // All variables defined in the route are required, and their values must
 // conform to the corresponding patterns.
+//
+// Path variables are percent-encoded as needed. If the route matches the
+// encoded path (see Router.UseEncodedPath), a value containing a slash is
+// built as a single segment with an encoded slash, which is kept in the
+// RawPath of the resulting url; already percent-encoded sequences, such as
+// those returned by Vars for such routes, are kept as they are. Host
+// variables containing non-ASCII characters are converted to punycode.
 func (r *Route) URL(pairs ...string) (*url.URL, error) {
 	if r.err != nil {
 		return nil, r.err
@@ -612,19 +671,23 @@ func (r *Route) URL(pairs ...string) (*url.URL, error) {
 	if err != nil {
 		return nil, err
 	}
-	var scheme, host, path string
+	u := &url.URL{}
 	queries := make([]string, 0, len(r.regexp.queries))
 	if r.regexp.host != nil {
-		if host, err = r.regexp.host.url(values); err != nil {
+		if u.Host, err = r.regexp.host.url(values); err != nil {
 			return nil, err
 		}
-		scheme = "http"
+		u.Scheme = "http"
 		if r.buildScheme != "" {
-			scheme = r.buildScheme
+			u.Scheme = r.buildScheme
 		}
 	}
 	if r.regexp.path != nil {
-		if path, err = r.regexp.path.url(values); err != nil {
+		path, err := r.regexp.path.url(values)
+		if err != nil {
+			return nil, err
+		}
+		if err = r.regexp.path.setURLPath(u, path); err != nil {
 			return nil, err
 		}
 	}
@@ -635,12 +698,8 @@ func (r *Route) URL(pairs ...string) (*url.URL, error) {
 		}
 		queries = append(queries, query)
 	}
-	return &url.URL{
-		Scheme:   scheme,
-		Host:     host,
-		Path:     path,
-		RawQuery: strings.Join(queries, "&"),
-	}, nil
+	u.RawQuery = strings.Join(queries, "&")
+	return u, nil
 }
 
 // URLHost builds the host part of the URL for a route. See Route.URL().
@@ -689,9 +748,41 @@ func (r *Route) URLPath(pairs ...string) (*url.URL, error) {
 	if err != nil {
 		return nil, err
 	}
-	return &url.URL{
-		Path: path,
-	}, nil
+	u := &url.URL{}
+	if err = r.regexp.path.setURLPath(u, path); err != nil {
+		return nil, err
+	}
+	return u, nil
+}
+
+// AbsoluteURL builds an absolute URL for the route, relative to the given
+// request. See Route.URL().
+//
+// If the route doesn't define a host, the host of the request is used. The
+// scheme is the first argument that was passed to Schemes or, if there is
+// none, the scheme of the request. If the router serving the request trusts
+// forwarding headers (see Router.TrustForwardedHeaders), the valid scheme and
+// host they carry take precedence over those of the request.
+func (r *Route) AbsoluteURL(req *http.Request, pairs ...string) (*url.URL, error) {
+	u, err := r.URL(pairs...)
+	if err != nil {
+		return nil, err
+	}
+	headers := NoForwardedHeaders
+	if router := CurrentRouter(req); router != nil {
+		headers = router.forwardedHeaders
+	}
+	if u.Host == "" {
+		u.Host = requestHost(req, headers)
+	}
+	u.Scheme = r.buildScheme
+	if u.Scheme == "" {
+		u.Scheme = requestScheme(req, headers)
+	}
+	if u.Path == "" {
+		u.Path = "/"
+	}
+	return u, nil
 }
 
 // GetPathTemplate returns the template used to build the
@@ -792,6 +883,108 @@ func (r *Route) GetHostTemplate() (string, error) {
 	return r.regexp.host.template, nil
 }
 
+// GetSchemes returns the schemes the route matches against.
+// This is useful for building simple REST API documentation and for instrumentation
+// against third-party services.
+// An error will be returned if route does not have schemes.
+func (r *Route) GetSchemes() ([]string, error) {
+	if r.err != nil {
+		return nil, r.err
+	}
+	for _, m := range r.matchers {
+		if schemes, ok := m.(schemeMatcher); ok {
+			return []string(schemes), nil
+		}
+	}
+	return nil, errors.New("mux: route doesn't have schemes")
+}
+
+// GetHeaders returns the header values the route matches against, as given
+// to Headers. An empty value matches any value of the header.
+// This is useful for building simple REST API documentation and for instrumentation
+// against third-party services.
+// An error will be returned if route does not have headers.
+func (r *Route) GetHeaders() (map[string]string, error) {
+	if r.err != nil {
+		return nil, r.err
+	}
+	var headers map[string]string
+	for _, m := range r.matchers {
+		if hm, ok := m.(headerMatcher); ok {
+			if headers == nil {
+				headers = make(map[string]string, len(hm))
+			}
+			for k, v := range hm {
+				headers[k] = v
+			}
+		}
+	}
+	if headers == nil {
+		return nil, errors.New("mux: route doesn't have headers")
+	}
+	return headers, nil
+}
+
+// GetHeadersRegexp returns the regular expressions of the header values the
+// route matches against, as given to HeadersRegexp.
+// This is useful for building simple REST API documentation and for instrumentation
+// against third-party services.
+// An error will be returned if route does not have header regular expressions.
+func (r *Route) GetHeadersRegexp() (map[string]string, error) {
+	if r.err != nil {
+		return nil, r.err
+	}
+	var headers map[string]string
+	for _, m := range r.matchers {
+		if hm, ok := m.(headerRegexMatcher); ok {
+			if headers == nil {
+				headers = make(map[string]string, len(hm))
+			}
+			for k, v := range hm {
+				headers[k] = v.String()
+			}
+		}
+	}
+	if headers == nil {
+		return nil, errors.New("mux: route doesn't have header regular expressions")
+	}
+	return headers, nil
+}
+
+// GetMiddlewares returns the middlewares of the route, in the order they were
+// added with Use. The middlewares of the routers of the route aren't
+// included.
+// An error will be returned if route does not have middlewares.
+func (r *Route) GetMiddlewares() ([]MiddlewareFunc, error) {
+	if r.err != nil {
+		return nil, r.err
+	}
+	if len(r.middlewares) == 0 {
+		return nil, errors.New("mux: route doesn't have middlewares")
+	}
+	mws := make([]MiddlewareFunc, 0, len(r.middlewares))
+	for _, mw := range r.middlewares {
+		if fn, ok := mw.(MiddlewareFunc); ok {
+			mws = append(mws, fn)
+		} else {
+			mws = append(mws, mw.Middleware)
+		}
+	}
+	return mws, nil
+}
+
+// IsBuildOnly reports whether the route never matches and is only used to
+// build URLs. See BuildOnly.
+func (r *Route) IsBuildOnly() bool {
+	return r.buildOnly
+}
+
+// IsPathPrefix reports whether the path template of the route is matched as a
+// prefix of the request path. See PathPrefix.
+func (r *Route) IsPathPrefix() bool {
+	return r.regexp.path != nil && r.regexp.path.regexpType == regexpTypePrefix
+}
+
 // GetVarNames returns the names of all variables added by regexp matchers
 // These can be used to know which route variables should be passed into r.URL()
 func (r *Route) GetVarNames() ([]string, error) {
@@ -811,6 +1004,41 @@ func (r *Route) GetVarNames() ([]string, error) {
 	return varNames, nil
 }
 
+// wrapGroupMiddlewares wraps handler with the middlewares of the groups which
+// registered the route, from the innermost.
+func (r *Route) wrapGroupMiddlewares(handler http.Handler) http.Handler {
+	for g := r.group; g != nil && g.parent != nil; g = g.parent {
+		for i := len(g.middlewares) - 1; i >= 0; i-- {
+			handler = g.middlewares[i].Middleware(handler)
+		}
+	}
+	return handler
+}
+
+// addStickyVars appends to pairs the sticky variables of the route which
+// are not in pairs, taking their values from the variables of req.
+func (r *Route) addStickyVars(req *http.Request, pairs []string) []string {
+	if len(r.stickyVars) == 0 {
+		return pairs
+	}
+	vars := Vars(req)
+	// Never append to the backing array of the caller.
+	pairs = pairs[:len(pairs):len(pairs)]
+	for _, name := range r.stickyVars {
+		given := false
+		for i := 0; i < len(pairs); i += 2 {
+			if pairs[i] == name {
+				given = true
+				break
+			}
+		}
+		if value, ok := vars[name]; ok && !given {
+			pairs = append(pairs, name, value)
+		}
+	}
+	return pairs
+}
+
 // prepareVars converts the route variable pairs into a map. If the route has a
 // BuildVarsFunc, it is invoked.
 func (r *Route) prepareVars(pairs ...string) (map[string]string, error) {
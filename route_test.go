@@ -152,3 +152,433 @@ func TestRouteMetadata(t *testing.T) {
 		router.ServeHTTP(rw, req)
 	})
 }
+
+func TestInheritedMetadata(t *testing.T) {
+	r := NewRouter().Metadata("app", "shop")
+	r.Metadata("role", "user")
+	home := r.HandleFunc("/", nil)
+
+	admin := r.PathPrefix("/admin").Metadata("section", "admin").Subrouter().Metadata("role", "admin")
+	users := admin.HandleFunc("/users", nil)
+	audit := admin.HandleFunc("/audit", nil).Metadata("role", "auditor")
+
+	// Metadata set afterwards isn't inherited by existing routes.
+	r.Metadata("late", true)
+
+	tests := []struct {
+		title     string
+		route     *Route
+		own       map[any]any
+		effective map[any]any
+	}{
+		{
+			title:     "route of the root router",
+			route:     home,
+			own:       nil,
+			effective: map[any]any{"app": "shop", "role": "user"},
+		},
+		{
+			title:     "route of a subrouter",
+			route:     users,
+			own:       nil,
+			effective: map[any]any{"app": "shop", "role": "admin", "section": "admin"},
+		},
+		{
+			title:     "route overriding inherited metadata",
+			route:     audit,
+			own:       map[any]any{"role": "auditor"},
+			effective: map[any]any{"app": "shop", "role": "auditor", "section": "admin"},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.title, func(t *testing.T) {
+			if got := tc.route.GetMetadata(); !reflect.DeepEqual(got, tc.own) {
+				t.Errorf("Expected own metadata %v, got %v", tc.own, got)
+			}
+			if got := tc.route.GetEffectiveMetadata(); !reflect.DeepEqual(got, tc.effective) {
+				t.Errorf("Expected effective metadata %v, got %v", tc.effective, got)
+			}
+			for k, v := range tc.effective {
+				if got, err := tc.route.GetMetadataValue(k); err != nil || got != v {
+					t.Errorf("Expected %v for %v, got %v (%v)", v, k, got, err)
+				}
+				if !tc.route.MetadataContains(k) {
+					t.Errorf("Expected metadata to contain %v", k)
+				}
+			}
+			if tc.route.MetadataContains("late") {
+				t.Error("Expected metadata set afterwards not to be inherited")
+			}
+		})
+	}
+
+	if got, expected := r.GetMetadata(), (map[any]any{"app": "shop", "role": "user", "late": true}); !reflect.DeepEqual(got, expected) {
+		t.Errorf("Expected router metadata %v, got %v", expected, got)
+	}
+
+	// Writing to the returned maps changes nothing.
+	r.GetMetadata()["role"] = "root"
+	home.GetEffectiveMetadata()["role"] = "root"
+	if got := home.GetMetadataValueOr("role", nil); got != "user" {
+		t.Errorf("Expected inherited metadata %q, got %v", "user", got)
+	}
+	if got := r.GetMetadata()["role"]; got != "user" {
+		t.Errorf("Expected router metadata %q, got %v", "user", got)
+	}
+}
+
+func TestAbsoluteURLServingRouter(t *testing.T) {
+	r := NewRouter()
+	var got string
+	route := r.HandleFunc("/a", func(w http.ResponseWriter, req *http.Request) {
+		u, err := CurrentRoute(req).AbsoluteURL(req)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		got = u.String()
+	})
+	// The setting applies to the routes registered before.
+	r.TrustForwardedHeaders(XForwardedHeaders)
+
+	req := newRequestHost("GET", "/a", "internal")
+	req.Header.Set("X-Forwarded-Host", "example.com")
+	r.ServeHTTP(NewRecorder(), req)
+	if expected := "http://example.com/a"; got != expected {
+		t.Errorf("Expected %q, got %q", expected, got)
+	}
+
+	// Without the router in the context, forwarding headers are ignored.
+	u, err := route.AbsoluteURL(req)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if expected := "http://internal/a"; u.String() != expected {
+		t.Errorf("Expected %q, got %q", expected, u.String())
+	}
+}
+
+func TestRouteGetters(t *testing.T) {
+	mw := func(h http.Handler) http.Handler { return h }
+	r := NewRouter()
+	route := r.PathPrefix("/api").
+		Schemes("https").
+		Headers("X-Requested-With", "XMLHttpRequest", "Accept", "").
+		HeadersRegexp("Content-Type", "application/(json|xml)").
+		Use(mw, mw)
+
+	if schemes, err := route.GetSchemes(); err != nil || !reflect.DeepEqual(schemes, []string{"https"}) {
+		t.Errorf("Expected [https], got %v (%v)", schemes, err)
+	}
+	expectedHeaders := map[string]string{"X-Requested-With": "XMLHttpRequest", "Accept": ""}
+	if headers, err := route.GetHeaders(); err != nil || !reflect.DeepEqual(headers, expectedHeaders) {
+		t.Errorf("Expected %v, got %v (%v)", expectedHeaders, headers, err)
+	}
+	expectedRegexp := map[string]string{"Content-Type": "application/(json|xml)"}
+	if headers, err := route.GetHeadersRegexp(); err != nil || !reflect.DeepEqual(headers, expectedRegexp) {
+		t.Errorf("Expected %v, got %v (%v)", expectedRegexp, headers, err)
+	}
+	if mws, err := route.GetMiddlewares(); err != nil || len(mws) != 2 {
+		t.Errorf("Expected 2 middlewares, got %d (%v)", len(mws), err)
+	}
+	if !route.IsPathPrefix() {
+		t.Error("Expected a path prefix")
+	}
+	if route.IsBuildOnly() {
+		t.Error("Expected the route not to be build-only")
+	}
+
+	plain := r.Path("/").BuildOnly()
+	if _, err := plain.GetSchemes(); err == nil {
+		t.Error("Expected an error for a route without schemes")
+	}
+	if _, err := plain.GetHeaders(); err == nil {
+		t.Error("Expected an error for a route without headers")
+	}
+	if _, err := plain.GetHeadersRegexp(); err == nil {
+		t.Error("Expected an error for a route without header regular expressions")
+	}
+	if _, err := plain.GetMiddlewares(); err == nil {
+		t.Error("Expected an error for a route without middlewares")
+	}
+	if plain.IsPathPrefix() {
+		t.Error("Expected a full path")
+	}
+	if !plain.IsBuildOnly() {
+		t.Error("Expected the route to be build-only")
+	}
+
+	invalid := r.Headers("odd").Schemes("https")
+	if _, err := invalid.GetSchemes(); err == nil {
+		t.Error("Expected the error of the route")
+	}
+}
+
+func TestURLEncoding(t *testing.T) {
+	tests := []struct {
+		title       string
+		encodedPath bool
+		host        string
+		path        string
+		pairs       []string
+		wantPath    string
+		wantRawPath string
+		wantString  string
+		wantErr     bool
+	}{
+		{
+			title:      "space in path variable",
+			path:       "/files/{name}",
+			pairs:      []string{"name", "a b"},
+			wantPath:   "/files/a b",
+			wantString: "/files/a%20b",
+		},
+		{
+			title:      "non-ASCII path variable",
+			path:       "/files/{name}",
+			pairs:      []string{"name", "ü"},
+			wantPath:   "/files/ü",
+			wantString: "/files/%C3%BC",
+		},
+		{
+			title:       "encoded path, slash in variable",
+			encodedPath: true,
+			path:        "/files/{name}",
+			pairs:       []string{"name", "a/b c"},
+			wantPath:    "/files/a/b c",
+			wantRawPath: "/files/a%2Fb%20c",
+			wantString:  "/files/a%2Fb%20c",
+		},
+		{
+			title:       "encoded path, already encoded variable",
+			encodedPath: true,
+			path:        "/files/{name}",
+			pairs:       []string{"name", "a%2Fb"},
+			wantPath:    "/files/a/b",
+			wantRawPath: "/files/a%2Fb",
+			wantString:  "/files/a%2Fb",
+		},
+		{
+			title:       "encoded path, variable validated on the encoded form",
+			encodedPath: true,
+			path:        "/files/{name:[a-z]+}",
+			pairs:       []string{"name", "a/b"},
+			wantErr:     true,
+		},
+		{
+			title:      "non-ASCII host variable",
+			host:       "{sub}.example.com",
+			path:       "/",
+			pairs:      []string{"sub", "Bücher"},
+			wantPath:   "/",
+			wantString: "http://xn--bcher-kva.example.com/",
+		},
+		{
+			title:      "non-ASCII host variable with a port",
+			host:       "{tld}.example.{cc}:8080",
+			path:       "/",
+			pairs:      []string{"tld", "a", "cc", "рф"},
+			wantPath:   "/",
+			wantString: "http://a.example.xn--p1ai:8080/",
+		},
+		{
+			title:   "variables matching without the template",
+			path:    "/{a:[a-z]+$}/x",
+			pairs:   []string{"a", "b"},
+			wantErr: true,
+		},
+		{
+			title:   "host variable validated on the ASCII form",
+			host:    "{sub:[a-zäöü]+}.example.com",
+			path:    "/",
+			pairs:   []string{"sub", "bücher"},
+			wantErr: true,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.title, func(t *testing.T) {
+			r := NewRouter()
+			if tc.encodedPath {
+				r.UseEncodedPath()
+			}
+			route := r.NewRoute()
+			if tc.host != "" {
+				route.Host(tc.host)
+			}
+			route.Path(tc.path)
+
+			u, err := route.URL(tc.pairs...)
+			if tc.wantErr {
+				if err == nil {
+					t.Fatalf("Expected an error, got %q", u)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if u.Path != tc.wantPath {
+				t.Errorf("Expected path %q, got %q", tc.wantPath, u.Path)
+			}
+			if u.RawPath != tc.wantRawPath {
+				t.Errorf("Expected raw path %q, got %q", tc.wantRawPath, u.RawPath)
+			}
+			if got := u.String(); got != tc.wantString {
+				t.Errorf("Expected %q, got %q", tc.wantString, got)
+			}
+
+			if tc.host == "" {
+				req := newRequest("GET", "http://localhost"+u.String())
+				var match RouteMatch
+				if !route.Match(req, &match) {
+					t.Errorf("Built URL %q does not match the route", u)
+				}
+			}
+		})
+	}
+}
+
+func TestAbsoluteURL(t *testing.T) {
+	tests := []struct {
+		title    string
+		trust    ForwardedHeaders
+		route    func(r *Router) *Route
+		request  *http.Request
+		headers  map[string]string
+		expected string
+	}{
+		{
+			title:    "host and scheme from the request",
+			route:    func(r *Router) *Route { return r.Path("/articles/{id}") },
+			request:  newRequestHost("GET", "/", "example.com:8080"),
+			expected: "http://example.com:8080/articles/42",
+		},
+		{
+			title:    "https request",
+			route:    func(r *Router) *Route { return r.Path("/articles/{id}") },
+			request:  newRequestHost("GET", "https://example.com/", "example.com"),
+			expected: "https://example.com/articles/42",
+		},
+		{
+			title:    "route host and scheme take precedence",
+			route:    func(r *Router) *Route { return r.Host("api.example.com").Schemes("https").Path("/articles/{id}") },
+			request:  newRequestHost("GET", "/", "example.com"),
+			expected: "https://api.example.com/articles/42",
+		},
+		{
+			title:    "route host with the scheme of the request",
+			route:    func(r *Router) *Route { return r.Host("api.example.com").Path("/articles/{id}") },
+			request:  newRequestHost("GET", "https://example.com/", "example.com"),
+			expected: "https://api.example.com/articles/42",
+		},
+		{
+			title:    "untrusted forwarding headers are ignored",
+			route:    func(r *Router) *Route { return r.Path("/articles/{id}") },
+			request:  newRequestHost("GET", "/", "internal:8080"),
+			headers:  map[string]string{"X-Forwarded-Proto": "https", "X-Forwarded-Host": "example.com"},
+			expected: "http://internal:8080/articles/42",
+		},
+		{
+			title:    "trusted X-Forwarded headers",
+			trust:    XForwardedHeaders,
+			route:    func(r *Router) *Route { return r.Path("/articles/{id}") },
+			request:  newRequestHost("GET", "/", "internal:8080"),
+			headers:  map[string]string{"X-Forwarded-Proto": "https, http", "X-Forwarded-Host": "example.com"},
+			expected: "https://example.com/articles/42",
+		},
+		{
+			title:    "trusted Forwarded header",
+			trust:    RFC7239Headers,
+			route:    func(r *Router) *Route { return r.Path("/articles/{id}") },
+			request:  newRequestHost("GET", "/", "internal:8080"),
+			headers:  map[string]string{"Forwarded": `for=192.0.2.60;proto=https;host="example.com", for=198.51.100.17`},
+			expected: "https://example.com/articles/42",
+		},
+		{
+			title:   "trusted X-Forwarded headers ignore the Forwarded header",
+			trust:   XForwardedHeaders,
+			route:   func(r *Router) *Route { return r.Path("/articles/{id}") },
+			request: newRequestHost("GET", "/", "internal:8080"),
+			headers: map[string]string{
+				"Forwarded":         "proto=http;host=evil.example",
+				"X-Forwarded-Proto": "https",
+				"X-Forwarded-Host":  "example.com",
+			},
+			expected: "https://example.com/articles/42",
+		},
+		{
+			title:    "trusted X-Forwarded headers ignore a lone Forwarded header",
+			trust:    XForwardedHeaders,
+			route:    func(r *Router) *Route { return r.Path("/articles/{id}") },
+			request:  newRequestHost("GET", "/", "internal:8080"),
+			headers:  map[string]string{"Forwarded": "proto=https;host=evil.example"},
+			expected: "http://internal:8080/articles/42",
+		},
+		{
+			title:    "trusted Forwarded header ignores X-Forwarded headers",
+			trust:    RFC7239Headers,
+			route:    func(r *Router) *Route { return r.Path("/articles/{id}") },
+			request:  newRequestHost("GET", "/", "internal:8080"),
+			headers:  map[string]string{"X-Forwarded-Proto": "https", "X-Forwarded-Host": "evil.example"},
+			expected: "http://internal:8080/articles/42",
+		},
+		{
+			title:    "invalid forwarded scheme",
+			trust:    RFC7239Headers,
+			route:    func(r *Router) *Route { return r.Path("/articles/{id}") },
+			request:  newRequestHost("GET", "/", "internal"),
+			headers:  map[string]string{"Forwarded": "proto=javascript"},
+			expected: "http://internal/articles/42",
+		},
+		{
+			title:    "invalid forwarded host",
+			trust:    XForwardedHeaders,
+			route:    func(r *Router) *Route { return r.Path("/articles/{id}") },
+			request:  newRequestHost("GET", "/", "internal"),
+			headers:  map[string]string{"X-Forwarded-Host": "evil.example/x?"},
+			expected: "http://internal/articles/42",
+		},
+		{
+			title:    "forwarded host with user info",
+			trust:    RFC7239Headers,
+			route:    func(r *Router) *Route { return r.Path("/articles/{id}") },
+			request:  newRequestHost("GET", "/", "internal"),
+			headers:  map[string]string{"Forwarded": `host="example.com@evil.example"`},
+			expected: "http://internal/articles/42",
+		},
+		{
+			title:    "forwarded host with an invalid port",
+			trust:    XForwardedHeaders,
+			route:    func(r *Router) *Route { return r.Path("/articles/{id}") },
+			request:  newRequestHost("GET", "/", "internal"),
+			headers:  map[string]string{"X-Forwarded-Host": "example.com:http"},
+			expected: "http://internal/articles/42",
+		},
+		{
+			title:    "forwarded IPv6 host with a port",
+			trust:    RFC7239Headers,
+			route:    func(r *Router) *Route { return r.Path("/articles/{id}") },
+			request:  newRequestHost("GET", "/", "internal"),
+			headers:  map[string]string{"Forwarded": `proto=HTTPS;host="[2001:db8::1]:8443"`},
+			expected: "https://[2001:db8::1]:8443/articles/42",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.title, func(t *testing.T) {
+			r := NewRouter().TrustForwardedHeaders(tc.trust)
+			for k, v := range tc.headers {
+				tc.request.Header.Set(k, v)
+			}
+			req := requestWithRouter(tc.request, r)
+			u, err := tc.route(r).AbsoluteURL(req, "id", "42")
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if got := u.String(); got != tc.expected {
+				t.Errorf("Expected %q, got %q", tc.expected, got)
+			}
+		})
+	}
+}
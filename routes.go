@@ -0,0 +1,216 @@
+// Copyright 2012 The Gorilla Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package mux
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"text/tabwriter"
+)
+
+// RouteInfo describes a route of a router. See Router.Routes.
+type RouteInfo struct {
+	// Route is the described route.
+	Route *Route `json:"-"`
+	// Name is the fully qualified name of the route, if any.
+	Name string `json:"name,omitempty"`
+	// Host is the host template of the route, if any.
+	Host string `json:"host,omitempty"`
+	// Path is the path template of the route, if any.
+	Path string `json:"path,omitempty"`
+	// PathPrefix reports whether Path is a prefix.
+	PathPrefix bool `json:"pathPrefix,omitempty"`
+	// Queries are the query templates of the route.
+	Queries []string `json:"queries,omitempty"`
+	// Methods are the methods matched by the route.
+	Methods []string `json:"methods,omitempty"`
+	// Schemes are the schemes matched by the route.
+	Schemes []string `json:"schemes,omitempty"`
+	// Headers are the header values matched by the route.
+	Headers map[string]string `json:"headers,omitempty"`
+	// HeadersRegexp are the regular expressions of the header values matched
+	// by the route.
+	HeadersRegexp map[string]string `json:"headersRegexp,omitempty"`
+	// Metadata is the effective metadata of the route, including the
+	// metadata inherited from its routers.
+	Metadata map[any]any `json:"-"`
+	// Middlewares is the number of middlewares wrapping the handler of the
+	// route, including those of the routers and groups leading to it.
+	Middlewares int `json:"middlewares"`
+	// RedirectTarget is the name of the route targeted by the route, if it
+	// was registered with Router.Redirect.
+	RedirectTarget string `json:"redirectTarget,omitempty"`
+	// Ancestors describe the routes leading to the route, from the
+	// outermost, by their name or else their templates.
+	Ancestors []string `json:"ancestors,omitempty"`
+	// Err is the error of the route, if any.
+	Err error `json:"-"`
+}
+
+// MarshalJSON encodes the route information, with its metadata keys
+// formatted as strings, and its metadata values too if they can't be
+// encoded.
+func (ri RouteInfo) MarshalJSON() ([]byte, error) {
+	type routeInfo RouteInfo
+	v := struct {
+		routeInfo
+		Metadata map[string]any `json:"metadata,omitempty"`
+		Error    string         `json:"error,omitempty"`
+	}{routeInfo: routeInfo(ri)}
+	for key, value := range ri.Metadata {
+		if v.Metadata == nil {
+			v.Metadata = make(map[string]any, len(ri.Metadata))
+		}
+		if _, err := json.Marshal(value); err != nil {
+			value = fmt.Sprint(value)
+		}
+		v.Metadata[fmt.Sprint(key)] = value
+	}
+	if ri.Err != nil {
+		v.Error = ri.Err.Error()
+	}
+	return json.Marshal(v)
+}
+
+// Routes returns a description of every route of the router and its
+// subrouters, in the order used by Walk.
+func (r *Router) Routes() []RouteInfo {
+	owner := r.owner()
+	var infos []RouteInfo
+	_ = r.Walk(func(route *Route, router *Router, ancestors []*Route) error {
+		info := RouteInfo{
+			Route:          route,
+			Name:           route.name,
+			Metadata:       route.GetEffectiveMetadata(),
+			Middlewares:    len(owner.middlewares) + len(route.middlewares),
+			RedirectTarget: route.redirectTarget,
+			Err:            route.err,
+		}
+		if route.regexp.host != nil {
+			info.Host = route.regexp.host.template
+		}
+		if route.regexp.path != nil {
+			info.Path = route.regexp.path.template
+			info.PathPrefix = route.regexp.path.regexpType == regexpTypePrefix
+		}
+		for _, q := range route.regexp.queries {
+			info.Queries = append(info.Queries, q.template)
+		}
+		for _, m := range route.matchers {
+			switch m := m.(type) {
+			case methodMatcher:
+				info.Methods = append(info.Methods, m...)
+			case schemeMatcher:
+				info.Schemes = append(info.Schemes, m...)
+			case headerMatcher:
+				if info.Headers == nil {
+					info.Headers = make(map[string]string, len(m))
+				}
+				for k, v := range m {
+					info.Headers[k] = v
+				}
+			case headerRegexMatcher:
+				if info.HeadersRegexp == nil {
+					info.HeadersRegexp = make(map[string]string, len(m))
+				}
+				for k, v := range m {
+					info.HeadersRegexp[k] = v.String()
+				}
+			}
+		}
+		info.Middlewares += groupMiddlewares(route)
+		for _, a := range ancestors {
+			info.Ancestors = append(info.Ancestors, describeRoute(a))
+			info.Middlewares += groupMiddlewares(a)
+			if sr := subrouterOf(a); sr != nil {
+				info.Middlewares += len(sr.middlewares)
+			}
+		}
+		infos = append(infos, info)
+		return nil
+	})
+	return infos
+}
+
+// groupMiddlewares returns the number of middlewares of the groups which
+// registered route.
+func groupMiddlewares(route *Route) int {
+	n := 0
+	for g := route.group; g != nil && g.parent != nil; g = g.parent {
+		n += len(g.middlewares)
+	}
+	return n
+}
+
+// subrouterOf returns the subrouter of a route, either created with
+// Route.Subrouter or set as its handler, or nil if it has none.
+func subrouterOf(route *Route) *Router {
+	for _, m := range route.matchers {
+		if sr, ok := m.(*Router); ok {
+			return sr
+		}
+	}
+	if sr, ok := route.handler.(*Router); ok {
+		return sr
+	}
+	return nil
+}
+
+// describeRoute returns the name of a route or else its templates.
+func describeRoute(route *Route) string {
+	if route.name != "" {
+		return route.name
+	}
+	var s string
+	if route.regexp.host != nil {
+		s = route.regexp.host.template
+	}
+	if route.regexp.path != nil {
+		s += route.regexp.path.template
+	}
+	return s
+}
+
+// DebugHandler returns a handler serving the table of the routes of router,
+// as returned by Router.Routes. The table is served as JSON if the request
+// has a "format=json" query parameter or accepts application/json, and as
+// plain text otherwise. For example:
+//
+//	r.Handle("/debug/routes", mux.DebugHandler(r))
+func DebugHandler(router *Router) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		routes := router.Routes()
+		if req.URL.Query().Get("format") == "json" || strings.Contains(req.Header.Get("Accept"), "application/json") {
+			w.Header().Set("Content-Type", "application/json")
+			enc := json.NewEncoder(w)
+			enc.SetIndent("", "  ")
+			_ = enc.Encode(routes)
+			return
+		}
+
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(tw, "NAME\tMETHODS\tHOST\tPATH\tQUERIES\tMIDDLEWARES")
+		for _, ri := range routes {
+			path := strings.Repeat("  ", len(ri.Ancestors)) + ri.Path
+			if ri.PathPrefix {
+				path += "*"
+			}
+			fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%d\n",
+				orDash(ri.Name), orDash(strings.Join(ri.Methods, ",")), orDash(ri.Host),
+				orDash(path), orDash(strings.Join(ri.Queries, "&")), ri.Middlewares)
+		}
+		_ = tw.Flush()
+	})
+}
+
+func orDash(s string) string {
+	if strings.TrimSpace(s) == "" {
+		return "-"
+	}
+	return s
+}
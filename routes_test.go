@@ -0,0 +1,83 @@
+package mux
+
+import (
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestRoutes(t *testing.T) {
+	mw := func(h http.Handler) http.Handler { return h }
+
+	r := NewRouter()
+	r.Use(mw)
+	r.HandleFunc("/", nil).Methods("GET").Name("home").Metadata("key", "value")
+	admin := r.Host("admin.example.com").PathPrefix("/admin").Schemes("https").Subrouter()
+	admin.Use(mw, mw)
+	admin.HandleFunc("/users/{id}", nil).
+		Methods("GET", "DELETE").
+		Headers("X-Requested-With", "XMLHttpRequest").
+		HeadersRegexp("Content-Type", "application/(text|json)").
+		Queries("fields", "{fields}").
+		Name("user").
+		Use(mw)
+
+	routes := r.Routes()
+	if len(routes) != 3 {
+		t.Fatalf("Expected 3 routes, got %d", len(routes))
+	}
+
+	expected := RouteInfo{
+		Route:         r.Get("user"),
+		Name:          "user",
+		Host:          "admin.example.com",
+		Path:          "/admin/users/{id}",
+		Queries:       []string{"fields={fields}"},
+		Methods:       []string{"GET", "DELETE"},
+		Schemes:       []string{"https"},
+		Headers:       map[string]string{"X-Requested-With": "XMLHttpRequest"},
+		HeadersRegexp: map[string]string{"Content-Type": "application/(text|json)"},
+		Middlewares:   4,
+		Ancestors:     []string{"admin.example.com/admin"},
+	}
+	if !reflect.DeepEqual(routes[2], expected) {
+		t.Errorf("Expected %+v, got %+v", expected, routes[2])
+	}
+
+	if parent := routes[1]; !parent.PathPrefix || parent.Path != "/admin" || parent.Middlewares != 1 {
+		t.Errorf("Unexpected subrouter route %+v", parent)
+	}
+
+	b, err := json.Marshal(routes[0])
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if !strings.Contains(string(b), `"metadata":{"key":"value"}`) {
+		t.Errorf("Expected metadata in JSON, got %s", b)
+	}
+}
+
+func TestDebugHandler(t *testing.T) {
+	r := NewRouter()
+	r.HandleFunc("/articles/{id}", nil).Methods("GET").Name("article")
+	r.Handle("/debug/routes", DebugHandler(r))
+
+	rec := NewRecorder()
+	r.ServeHTTP(rec, newRequest("GET", "http://localhost/debug/routes"))
+	body := rec.Body.String()
+	if !strings.HasPrefix(body, "NAME") || !strings.Contains(body, "article") || !strings.Contains(body, "/articles/{id}") {
+		t.Errorf("Unexpected text output:\n%s", body)
+	}
+
+	rec = NewRecorder()
+	r.ServeHTTP(rec, newRequest("GET", "http://localhost/debug/routes?format=json"))
+	var routes []map[string]any
+	if err := json.Unmarshal(rec.Body.Bytes(), &routes); err != nil {
+		t.Fatalf("Invalid JSON: %v\n%s", err, rec.Body.String())
+	}
+	if len(routes) != 2 || routes[0]["name"] != "article" {
+		t.Errorf("Unexpected JSON output: %v", routes)
+	}
+}
@@ -0,0 +1,404 @@
+// Copyright 2012 The Gorilla Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package mux
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/gorilla/mux/internal/routetpl"
+)
+
+// RouteSnapshot is the stable description of a route, as serialized by
+// WriteSnapshot. See Router.Snapshot.
+type RouteSnapshot struct {
+	// Name is the fully qualified name of the route, if any.
+	Name string `json:"name,omitempty"`
+	// Methods are the methods matched by the route, sorted.
+	Methods []string `json:"methods,omitempty"`
+	// Schemes are the schemes matched by the route, sorted.
+	Schemes []string `json:"schemes,omitempty"`
+	// Host is the host template of the route, if any.
+	Host string `json:"host,omitempty"`
+	// Path is the path template of the route, if any.
+	Path string `json:"path,omitempty"`
+	// PathPrefix reports whether Path is a prefix.
+	PathPrefix bool `json:"pathPrefix,omitempty"`
+	// Queries are the query templates of the route.
+	Queries []string `json:"queries,omitempty"`
+	// Vars maps the variables of the route to their patterns, including the
+	// default ones.
+	Vars map[string]string `json:"vars,omitempty"`
+}
+
+// Snapshot returns a stable description of the routes of the router and its
+// subrouters which handle requests themselves, in the order used by Walk.
+// Routes leading to a subrouter are left out, their conditions being part of
+// those of the routes of the subrouter.
+//
+// Snapshots are meant to be checked in with WriteSnapshot and compared with
+// DiffRoutes, to catch changes breaking the clients of the router.
+func (r *Router) Snapshot() []RouteSnapshot {
+	var routes []RouteSnapshot
+	for _, info := range r.Routes() {
+		if subrouterOf(info.Route) != nil {
+			continue
+		}
+		rs := RouteSnapshot{
+			Name:       info.Name,
+			Methods:    sortedCopy(info.Methods),
+			Schemes:    sortedCopy(info.Schemes),
+			Host:       info.Host,
+			Path:       info.Path,
+			PathPrefix: info.PathPrefix,
+			Queries:    info.Queries,
+		}
+		addVars := func(tpl, defaultPattern string) {
+			vars, _ := routetpl.Parse(tpl, defaultPattern)
+			for _, v := range vars {
+				if rs.Vars == nil {
+					rs.Vars = make(map[string]string)
+				}
+				rs.Vars[v.Name] = v.Pattern
+			}
+		}
+		addVars(rs.Host, routetpl.DefaultHostPattern)
+		addVars(rs.Path, routetpl.DefaultPathPattern)
+		for _, q := range rs.Queries {
+			_, value := routetpl.SplitQuery(q)
+			addVars(value, routetpl.DefaultQueryPattern)
+		}
+		routes = append(routes, rs)
+	}
+	return routes
+}
+
+func sortedCopy(s []string) []string {
+	if len(s) == 0 {
+		return nil
+	}
+	c := make([]string, len(s))
+	copy(c, s)
+	sort.Strings(c)
+	return c
+}
+
+// WriteSnapshot writes routes as indented JSON, e.g. to a golden file.
+func WriteSnapshot(w io.Writer, routes []RouteSnapshot) error {
+	if routes == nil {
+		routes = []RouteSnapshot{}
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(routes)
+}
+
+// ReadSnapshot reads routes written by WriteSnapshot.
+func ReadSnapshot(r io.Reader) ([]RouteSnapshot, error) {
+	var routes []RouteSnapshot
+	if err := json.NewDecoder(r).Decode(&routes); err != nil {
+		return nil, fmt.Errorf("mux: reading snapshot: %w", err)
+	}
+	return routes, nil
+}
+
+// String returns the methods and templates of the route, followed by its
+// name if any.
+func (rs RouteSnapshot) String() string {
+	var b strings.Builder
+	if len(rs.Methods) > 0 {
+		b.WriteString(strings.Join(rs.Methods, ","))
+		b.WriteByte(' ')
+	}
+	if len(rs.Schemes) > 0 {
+		b.WriteString(strings.Join(rs.Schemes, ",") + "://")
+	}
+	b.WriteString(rs.Host)
+	b.WriteString(rs.Path)
+	if rs.PathPrefix {
+		b.WriteByte('*')
+	}
+	if len(rs.Queries) > 0 {
+		b.WriteString("?" + strings.Join(rs.Queries, "&"))
+	}
+	if rs.Name != "" {
+		fmt.Fprintf(&b, " (%s)", rs.Name)
+	}
+	return b.String()
+}
+
+// ChangeKind is the kind of a RouteChange.
+type ChangeKind string
+
+// Kinds of route changes reported by DiffRoutes.
+const (
+	// ChangeAdded reports a new route. It is compatible.
+	ChangeAdded ChangeKind = "added"
+	// ChangeRemoved reports a removed route. It is breaking.
+	ChangeRemoved ChangeKind = "removed"
+	// ChangeRenamed reports a route whose name changed. It is compatible
+	// for clients, but breaks the code building URLs by name.
+	ChangeRenamed ChangeKind = "renamed"
+	// ChangeTemplate reports a route whose host or path changed. It is
+	// breaking, unless only the names of variables changed.
+	ChangeTemplate ChangeKind = "template"
+	// ChangeMethods reports a route whose methods changed. It is breaking
+	// if methods were removed.
+	ChangeMethods ChangeKind = "methods"
+	// ChangeSchemes reports a route whose schemes changed. It is breaking
+	// if schemes were removed.
+	ChangeSchemes ChangeKind = "schemes"
+	// ChangeQueries reports a route whose query parameters changed. It is
+	// breaking if a parameter was added or its value changed.
+	ChangeQueries ChangeKind = "queries"
+	// ChangePattern reports a variable whose pattern changed. It is
+	// breaking unless the pattern was loosened to the default one or to a
+	// pattern matching anything.
+	ChangePattern ChangeKind = "pattern"
+)
+
+// RouteChange is a difference between two snapshots of a route table. See
+// DiffRoutes.
+type RouteChange struct {
+	// Kind is the kind of the change.
+	Kind ChangeKind `json:"kind"`
+	// Breaking reports whether the change may break existing clients.
+	Breaking bool `json:"breaking"`
+	// Old is the route before the change, nil if it was added.
+	Old *RouteSnapshot `json:"old,omitempty"`
+	// New is the route after the change, nil if it was removed.
+	New *RouteSnapshot `json:"new,omitempty"`
+	// Message describes the change.
+	Message string `json:"message"`
+}
+
+// String returns the message of the change prefixed by its classification.
+func (c RouteChange) String() string {
+	if c.Breaking {
+		return "breaking: " + c.Message
+	}
+	return "compatible: " + c.Message
+}
+
+// DiffRoutes compares two snapshots of a route table and returns their
+// differences, classified as breaking or compatible.
+//
+// Routes of old are paired with routes of current by name first, then by
+// templates and methods, and then by templates only, ignoring the names and
+// patterns of variables. Unpaired routes are reported as removed or added.
+//
+// Whether a changed pattern accepts fewer values can't be decided in
+// general, so any change of pattern is reported as breaking, except to the
+// default pattern of the variable or to ".*" or ".+".
+func DiffRoutes(old, current []RouteSnapshot) []RouteChange {
+	pairs := make([]int, len(old))
+	paired := make([]bool, len(current))
+	for i := range pairs {
+		pairs[i] = -1
+	}
+	pair := func(key func(RouteSnapshot) string) {
+		for i, o := range old {
+			if pairs[i] >= 0 || key(o) == "" {
+				continue
+			}
+			for j, n := range current {
+				if !paired[j] && key(o) == key(n) {
+					pairs[i], paired[j] = j, true
+					break
+				}
+			}
+		}
+	}
+	pair(func(rs RouteSnapshot) string { return rs.Name })
+	pair(func(rs RouteSnapshot) string { return routeShape(rs) + " " + strings.Join(rs.Methods, ",") })
+	pair(routeShape)
+
+	var changes []RouteChange
+	for i := range old {
+		o := &old[i]
+		if pairs[i] < 0 {
+			changes = append(changes, RouteChange{
+				Kind:     ChangeRemoved,
+				Breaking: true,
+				Old:      o,
+				Message:  fmt.Sprintf("route %s removed", o),
+			})
+			continue
+		}
+		changes = append(changes, diffRoute(o, &current[pairs[i]])...)
+	}
+	for j := range current {
+		if !paired[j] {
+			changes = append(changes, RouteChange{
+				Kind:    ChangeAdded,
+				New:     &current[j],
+				Message: fmt.Sprintf("route %s added", &current[j]),
+			})
+		}
+	}
+	return changes
+}
+
+// diffRoute returns the differences between two paired routes.
+func diffRoute(o, n *RouteSnapshot) []RouteChange {
+	var changes []RouteChange
+	add := func(kind ChangeKind, breaking bool, format string, args ...any) {
+		changes = append(changes, RouteChange{
+			Kind:     kind,
+			Breaking: breaking,
+			Old:      o,
+			New:      n,
+			Message:  fmt.Sprintf("route %s: ", o) + fmt.Sprintf(format, args...),
+		})
+	}
+
+	if o.Name != n.Name {
+		add(ChangeRenamed, false, "renamed from %q to %q", o.Name, n.Name)
+	}
+
+	oldTpl, newTpl := o.Host+o.Path, n.Host+n.Path
+	if routeShape(*o) != routeShape(*n) {
+		add(ChangeTemplate, true, "template changed from %q to %q", oldTpl, newTpl)
+	} else {
+		if stripTemplate(oldTpl) != stripTemplate(newTpl) {
+			add(ChangeTemplate, false, "variables renamed from %q to %q", oldTpl, newTpl)
+		}
+		diffPatterns(o.Host, n.Host, routetpl.DefaultHostPattern, add)
+		diffPatterns(o.Path, n.Path, routetpl.DefaultPathPattern, add)
+	}
+
+	diffSet(o.Methods, n.Methods, "methods", ChangeMethods, add)
+	diffSet(o.Schemes, n.Schemes, "schemes", ChangeSchemes, add)
+
+	oldQueries, newQueries := queryMap(o.Queries), queryMap(n.Queries)
+	for _, q := range n.Queries {
+		key, value := routetpl.SplitQuery(q)
+		oldValue, ok := oldQueries[key]
+		switch {
+		case !ok:
+			add(ChangeQueries, true, "query parameter %q added", key)
+		case normalizeTemplate(oldValue) != normalizeTemplate(value):
+			add(ChangeQueries, true, "query parameter %q changed from %q to %q", key, oldValue, value)
+		default:
+			diffPatterns(oldValue, value, routetpl.DefaultQueryPattern, add)
+		}
+	}
+	for _, q := range o.Queries {
+		key, _ := routetpl.SplitQuery(q)
+		if _, ok := newQueries[key]; !ok {
+			add(ChangeQueries, false, "query parameter %q removed", key)
+		}
+	}
+	return changes
+}
+
+// diffPatterns reports the changed patterns of the variables of two
+// templates with the same shape, paired by position.
+func diffPatterns(oldTpl, newTpl, defaultPattern string, add func(ChangeKind, bool, string, ...any)) {
+	oldVars, err1 := routetpl.Parse(oldTpl, defaultPattern)
+	newVars, err2 := routetpl.Parse(newTpl, defaultPattern)
+	if err1 != nil || err2 != nil || len(oldVars) != len(newVars) {
+		return
+	}
+	for i, ov := range oldVars {
+		nv := newVars[i]
+		if ov.Pattern == nv.Pattern {
+			continue
+		}
+		if patternLoosened(ov.Pattern, nv.Pattern, defaultPattern) {
+			add(ChangePattern, false, "variable %q loosened from %q to %q", nv.Name, ov.Pattern, nv.Pattern)
+		} else {
+			add(ChangePattern, true, "variable %q changed from %q to %q", nv.Name, ov.Pattern, nv.Pattern)
+		}
+	}
+}
+
+// patternLoosened reports whether the pattern to matches every value matched
+// by from, for the few cases where it is obvious.
+func patternLoosened(from, to, defaultPattern string) bool {
+	switch {
+	case to == ".*":
+		return true
+	case to == ".+":
+		return from != ".*"
+	case to == defaultPattern:
+		return from != ".*" && from != ".+"
+	}
+	return false
+}
+
+// diffSet reports the removed and added elements of two sorted sets of
+// methods or schemes, an empty set matching anything.
+func diffSet(old, current []string, what string, kind ChangeKind, add func(ChangeKind, bool, string, ...any)) {
+	switch {
+	case len(old) == 0 && len(current) == 0:
+	case len(old) == 0:
+		add(kind, true, "%s restricted to %s", what, strings.Join(current, ","))
+	case len(current) == 0:
+		add(kind, false, "%s restriction to %s removed", what, strings.Join(old, ","))
+	default:
+		if removed := setDiff(old, current); len(removed) > 0 {
+			add(kind, true, "%s %s removed", what, strings.Join(removed, ","))
+		}
+		if added := setDiff(current, old); len(added) > 0 {
+			add(kind, false, "%s %s added", what, strings.Join(added, ","))
+		}
+	}
+}
+
+// setDiff returns the elements of a which are not in b.
+func setDiff(a, b []string) []string {
+	var diff []string
+outer:
+	for _, x := range a {
+		for _, y := range b {
+			if strings.EqualFold(x, y) {
+				continue outer
+			}
+		}
+		diff = append(diff, x)
+	}
+	return diff
+}
+
+// queryMap maps the keys of query templates to their value templates.
+func queryMap(queries []string) map[string]string {
+	m := make(map[string]string, len(queries))
+	for _, q := range queries {
+		key, value := routetpl.SplitQuery(q)
+		m[key] = value
+	}
+	return m
+}
+
+// routeShape returns the host and path templates of a route, without the
+// names and patterns of their variables.
+func routeShape(rs RouteSnapshot) string {
+	shape := normalizeTemplate(rs.Host + rs.Path)
+	if rs.PathPrefix {
+		shape += "*"
+	}
+	return shape
+}
+
+var templateVar = regexp.MustCompile(`\{[^{}]*\}`)
+
+// stripTemplate returns tpl without the patterns of its variables.
+func stripTemplate(tpl string) string {
+	if s, err := routetpl.Strip(tpl); err == nil {
+		return s
+	}
+	return tpl
+}
+
+// normalizeTemplate returns tpl without the names and patterns of its
+// variables.
+func normalizeTemplate(tpl string) string {
+	return templateVar.ReplaceAllString(stripTemplate(tpl), "{}")
+}
@@ -0,0 +1,154 @@
+package mux
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestSnapshot(t *testing.T) {
+	r := NewRouter()
+	r.HandleFunc("/", nil).Name("home")
+	api := r.Host("{sub:api|www}.example.com").PathPrefix("/v1").Methods("PUT", "GET").Subrouter()
+	api.HandleFunc("/users/{id:[0-9]+}", nil).Queries("fields", "{fields}").Name("user")
+
+	routes := r.Snapshot()
+	expected := []RouteSnapshot{
+		{Name: "home", Path: "/"},
+		{
+			Name:    "user",
+			Methods: []string{"GET", "PUT"},
+			Host:    "{sub:api|www}.example.com",
+			Path:    "/v1/users/{id:[0-9]+}",
+			Queries: []string{"fields={fields}"},
+			Vars:    map[string]string{"sub": "api|www", "id": "[0-9]+", "fields": ".*"},
+		},
+	}
+	if !reflect.DeepEqual(routes, expected) {
+		t.Fatalf("Expected %+v, got %+v", expected, routes)
+	}
+
+	var b bytes.Buffer
+	if err := WriteSnapshot(&b, routes); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	read, err := ReadSnapshot(&b)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(read, expected) {
+		t.Errorf("Expected %+v, got %+v", expected, read)
+	}
+
+	if _, err := ReadSnapshot(strings.NewReader("{")); err == nil {
+		t.Error("Expected an error reading an invalid snapshot")
+	}
+}
+
+func TestDiffRoutes(t *testing.T) {
+	tests := []struct {
+		title    string
+		old, new []RouteSnapshot
+		expected []string
+	}{
+		{
+			title:    "no changes",
+			old:      []RouteSnapshot{{Name: "home", Path: "/"}},
+			new:      []RouteSnapshot{{Name: "home", Path: "/"}},
+			expected: nil,
+		},
+		{
+			title: "removed and added routes",
+			old:   []RouteSnapshot{{Path: "/a"}},
+			new:   []RouteSnapshot{{Path: "/b"}},
+			expected: []string{
+				`removed breaking: route /a removed`,
+				`added compatible: route /b added`,
+			},
+		},
+		{
+			title: "renamed route",
+			old:   []RouteSnapshot{{Name: "user", Methods: []string{"GET"}, Path: "/users/{id}"}},
+			new:   []RouteSnapshot{{Name: "users.show", Methods: []string{"GET"}, Path: "/users/{userID}"}},
+			expected: []string{
+				`renamed compatible: route GET /users/{id} (user): renamed from "user" to "users.show"`,
+				`template compatible: route GET /users/{id} (user): variables renamed from "/users/{id}" to "/users/{userID}"`,
+			},
+		},
+		{
+			title: "changed template",
+			old:   []RouteSnapshot{{Name: "user", Path: "/users/{id}"}},
+			new:   []RouteSnapshot{{Name: "user", Path: "/people/{id}"}},
+			expected: []string{
+				`template breaking: route /users/{id} (user): template changed from "/users/{id}" to "/people/{id}"`,
+			},
+		},
+		{
+			title: "changed methods",
+			old: []RouteSnapshot{
+				{Methods: []string{"GET", "PUT"}, Path: "/a"},
+				{Path: "/b"},
+				{Methods: []string{"GET"}, Path: "/c"},
+			},
+			new: []RouteSnapshot{
+				{Methods: []string{"GET", "PATCH"}, Path: "/a"},
+				{Methods: []string{"GET"}, Path: "/b"},
+				{Path: "/c"},
+			},
+			expected: []string{
+				`methods breaking: route GET,PUT /a: methods PUT removed`,
+				`methods compatible: route GET,PUT /a: methods PATCH added`,
+				`methods breaking: route /b: methods restricted to GET`,
+				`methods compatible: route GET /c: methods restriction to GET removed`,
+			},
+		},
+		{
+			title: "routes sharing a template are paired by methods",
+			old: []RouteSnapshot{
+				{Methods: []string{"GET"}, Path: "/a"},
+				{Methods: []string{"POST"}, Path: "/a"},
+			},
+			new: []RouteSnapshot{
+				{Methods: []string{"POST"}, Path: "/a"},
+			},
+			expected: []string{
+				`removed breaking: route GET /a removed`,
+			},
+		},
+		{
+			title: "changed patterns",
+			old:   []RouteSnapshot{{Path: "/{a:[a-z]+}/{b:[0-9]+}/{c}/{d:.+}"}},
+			new:   []RouteSnapshot{{Path: "/{a}/{b:[0-9]{1,3}}/{c:.*}/{d}"}},
+			expected: []string{
+				`pattern compatible: route /{a:[a-z]+}/{b:[0-9]+}/{c}/{d:.+}: variable "a" loosened from "[a-z]+" to "[^/]+"`,
+				`pattern breaking: route /{a:[a-z]+}/{b:[0-9]+}/{c}/{d:.+}: variable "b" changed from "[0-9]+" to "[0-9]{1,3}"`,
+				`pattern compatible: route /{a:[a-z]+}/{b:[0-9]+}/{c}/{d:.+}: variable "c" loosened from "[^/]+" to ".*"`,
+				`pattern breaking: route /{a:[a-z]+}/{b:[0-9]+}/{c}/{d:.+}: variable "d" changed from ".+" to "[^/]+"`,
+			},
+		},
+		{
+			title: "changed queries",
+			old:   []RouteSnapshot{{Path: "/a", Queries: []string{"x={x:[0-9]+}", "y=1", "z={z}"}}},
+			new:   []RouteSnapshot{{Path: "/a", Queries: []string{"x={x}", "y=2", "w={w}"}}},
+			expected: []string{
+				`pattern compatible: route /a?x={x:[0-9]+}&y=1&z={z}: variable "x" loosened from "[0-9]+" to ".*"`,
+				`queries breaking: route /a?x={x:[0-9]+}&y=1&z={z}: query parameter "y" changed from "1" to "2"`,
+				`queries breaking: route /a?x={x:[0-9]+}&y=1&z={z}: query parameter "w" added`,
+				`queries compatible: route /a?x={x:[0-9]+}&y=1&z={z}: query parameter "z" removed`,
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.title, func(t *testing.T) {
+			var got []string
+			for _, c := range DiffRoutes(tc.old, tc.new) {
+				got = append(got, string(c.Kind)+" "+c.String())
+			}
+			if !reflect.DeepEqual(got, tc.expected) {
+				t.Errorf("Expected:\n%s\ngot:\n%s", strings.Join(tc.expected, "\n"), strings.Join(got, "\n"))
+			}
+		})
+	}
+}
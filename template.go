@@ -0,0 +1,118 @@
+// Copyright 2012 The Gorilla Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package mux
+
+import (
+	"fmt"
+	"html/template"
+	"net/url"
+	"reflect"
+	"strconv"
+)
+
+// FuncMap returns functions building URLs for the named routes of the
+// router, for use in html/template and text/template templates. The returned
+// map can be passed as is to the Funcs method of both template packages.
+//
+// The functions take a route name followed by a sequence of key/value pairs
+// for the route variables, and return a template.URL:
+//
+//   - url builds the URL of the route, see Route.URL().
+//   - urlpath builds the path of the route, see Route.URLPath().
+//   - urlhost builds the host of the route, see Route.URLHost().
+//
+// Keys must be strings, and values can be of any string, boolean or numeric
+// type, or implement fmt.Stringer. For example:
+//
+//	tmpl := template.Must(template.New("").Funcs(r.FuncMap()).Parse(
+//	  `<a href="{{url "article" "category" .Category "id" .ID}}">`))
+//
+// Unknown routes and invalid variables are reported as template execution
+// errors.
+func (r *Router) FuncMap() map[string]any {
+	return map[string]any{
+		"url":     r.templateURLFunc((*Route).URL),
+		"urlpath": r.templateURLFunc((*Route).URLPath),
+		"urlhost": r.templateURLFunc((*Route).URLHost),
+	}
+}
+
+func (r *Router) templateURLFunc(build func(*Route, ...string) (*url.URL, error)) func(string, ...any) (template.URL, error) {
+	return func(name string, pairs ...any) (template.URL, error) {
+		route, err := r.getRoute(name)
+		if err != nil {
+			return "", err
+		}
+		strPairs, err := templatePairs(pairs)
+		if err != nil {
+			return "", fmt.Errorf("mux: route %q: %w", name, err)
+		}
+		u, err := build(route, strPairs...)
+		if err != nil {
+			return "", err
+		}
+		return template.URL(u.String()), nil // #nosec G203 -- built from a route template
+	}
+}
+
+// getRoute returns the route registered with the given name, or an error if
+// there is none.
+func (r *Router) getRoute(name string) (*Route, error) {
+	route := r.Get(name)
+	if route == nil {
+		return nil, fmt.Errorf("mux: no route named %q", name)
+	}
+	return route, nil
+}
+
+// templatePairs converts the key/value pairs passed to the template functions
+// to strings.
+func templatePairs(pairs []any) ([]string, error) {
+	if len(pairs)%2 != 0 {
+		return nil, fmt.Errorf("number of parameters must be multiple of 2, got %v", pairs)
+	}
+	s := make([]string, len(pairs))
+	for i, p := range pairs {
+		if i%2 == 0 {
+			key, ok := p.(string)
+			if !ok {
+				return nil, fmt.Errorf("route variable names must be strings, got %T", p)
+			}
+			s[i] = key
+			continue
+		}
+		value, err := formatScalar(p)
+		if err != nil {
+			return nil, fmt.Errorf("route variable %q: %w", s[i-1], err)
+		}
+		s[i] = value
+	}
+	return s, nil
+}
+
+// formatScalar formats a value of a string, boolean or numeric type, or
+// implementing fmt.Stringer.
+func formatScalar(v any) (string, error) {
+	switch v := v.(type) {
+	case string:
+		return v, nil
+	case fmt.Stringer:
+		return v.String(), nil
+	}
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.String:
+		return rv.String(), nil
+	case reflect.Bool:
+		return strconv.FormatBool(rv.Bool()), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(rv.Int(), 10), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return strconv.FormatUint(rv.Uint(), 10), nil
+	case reflect.Float32, reflect.Float64:
+		return strconv.FormatFloat(rv.Float(), 'g', -1, rv.Type().Bits()), nil
+	}
+	return "", fmt.Errorf("unsupported value of type %T", v)
+}
@@ -0,0 +1,98 @@
+package mux
+
+import (
+	htmltemplate "html/template"
+	"strings"
+	"testing"
+	texttemplate "text/template"
+	"time"
+)
+
+func TestFuncMap(t *testing.T) {
+	r := NewRouter()
+	r.Host("{sub}.example.com").
+		Path("/articles/{category}/{id:[0-9]+}").
+		Queries("q", "{q}").
+		Name("article")
+
+	tests := []struct {
+		title    string
+		tpl      string
+		data     any
+		expected string
+		wantErr  string
+	}{
+		{
+			title:    "url with scalar values",
+			tpl:      `{{url "article" "sub" "news" "category" .Category "id" .ID "q" .Q}}`,
+			data:     map[string]any{"Category": "tech", "ID": 42, "Q": true},
+			expected: "http://news.example.com/articles/tech/42?q=true",
+		},
+		{
+			title:    "urlpath",
+			tpl:      `{{urlpath "article" "category" "tech" "id" 42}}`,
+			expected: "/articles/tech/42",
+		},
+		{
+			title:    "urlhost with a fmt.Stringer",
+			tpl:      `{{urlhost "article" "sub" .}}`,
+			data:     time.Duration(0),
+			expected: "http://0s.example.com",
+		},
+		{
+			title:   "unknown route",
+			tpl:     `{{url "unknown"}}`,
+			wantErr: `no route named "unknown"`,
+		},
+		{
+			title:   "invalid variable",
+			tpl:     `{{urlpath "article" "category" "tech" "id" "x"}}`,
+			wantErr: `variable "x" doesn't match`,
+		},
+		{
+			title:   "odd number of parameters",
+			tpl:     `{{urlpath "article" "category"}}`,
+			wantErr: "multiple of 2",
+		},
+		{
+			title:   "non-scalar value",
+			tpl:     `{{urlpath "article" "category" .}}`,
+			data:    []string{"tech"},
+			wantErr: "unsupported value",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.title, func(t *testing.T) {
+			tmpl := htmltemplate.Must(htmltemplate.New("").Funcs(r.FuncMap()).Parse(`<a href="` + tc.tpl + `">`))
+			var b strings.Builder
+			err := tmpl.Execute(&b, tc.data)
+			if tc.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.wantErr) {
+					t.Fatalf("Expected an error containing %q, got %v", tc.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if expected := `<a href="` + tc.expected + `">`; b.String() != expected {
+				t.Errorf("Expected %q, got %q", expected, b.String())
+			}
+		})
+	}
+}
+
+func TestFuncMapTextTemplate(t *testing.T) {
+	r := NewRouter()
+	r.Path("/articles/{id}").Name("article")
+
+	tmpl := texttemplate.Must(texttemplate.New("").Funcs(r.FuncMap()).Parse(`{{url "article" "id" 7}}`))
+	var b strings.Builder
+	if err := tmpl.Execute(&b, nil); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if expected := "/articles/7"; b.String() != expected {
+		t.Errorf("Expected %q, got %q", expected, b.String())
+	}
+}
@@ -0,0 +1,250 @@
+// Copyright 2012 The Gorilla Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package mux
+
+import (
+	"encoding"
+	"fmt"
+	"net/http"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// TypedRoute is a handle to a route whose variables are described by the
+// fields of the struct type P. It is created with Register and builds URLs
+// from, and decodes route variables into, values of type P, so that route
+// handles and their parameter sets are checked by the compiler.
+//
+// Each field of P maps to the route variable named by its `mux` tag, or, if
+// the field has no tag, to the variable whose name equals the field name
+// ignoring case. Fields tagged `mux:"-"` and unexported fields are ignored.
+// Fields of embedded structs are used too, unless they are embedded through a
+// pointer.
+// Fields can be strings, booleans, integers, floats, or types implementing
+// encoding.TextMarshaler and encoding.TextUnmarshaler. For example:
+//
+//	type ArticleParams struct {
+//		Category string `mux:"category"`
+//		ID       int    `mux:"id"`
+//	}
+//
+//	article := mux.Register[ArticleParams](r, "/articles/{category}/{id:[0-9]+}", ArticleHandler)
+//
+//	// "/articles/technology/42"
+//	url, err := article.URL(ArticleParams{Category: "technology", ID: 42})
+//
+//	func ArticleHandler(w http.ResponseWriter, r *http.Request) {
+//		params, err := article.Params(r)
+//		...
+//	}
+type TypedRoute[P any] struct {
+	route  *Route
+	fields []typedField
+}
+
+// typedField maps a struct field to a route variable.
+type typedField struct {
+	index []int
+	name  string
+}
+
+// Register registers a new route with a matcher for the URL path and a
+// handler, and returns a typed handle to it. See Router.Handle().
+//
+// P must be a struct type whose fields match the variables of the route
+// exactly. A mismatch is reported as the route error, see Route.GetError().
+func Register[P any](r *Router, tpl string, handler http.Handler) *TypedRoute[P] {
+	route := r.Handle(tpl, handler)
+	t := &TypedRoute[P]{route: route}
+	if route.err != nil {
+		return t
+	}
+	varNames, _ := route.GetVarNames()
+	t.fields, route.err = typedFields(reflect.TypeOf((*P)(nil)).Elem(), varNames)
+	return t
+}
+
+// Route returns the underlying route, e.g. to add matchers or a name.
+func (t *TypedRoute[P]) Route() *Route {
+	return t.route
+}
+
+// URL builds a URL for the route from the fields of params.
+// See Route.URL().
+func (t *TypedRoute[P]) URL(params P) (*url.URL, error) {
+	if t.route.err != nil {
+		return nil, t.route.err
+	}
+	v := reflect.ValueOf(params)
+	pairs := make([]string, 0, len(t.fields)*2)
+	for _, f := range t.fields {
+		s, err := formatTypedValue(v.FieldByIndex(f.index))
+		if err != nil {
+			return nil, fmt.Errorf("mux: cannot format route variable %q: %w", f.name, err)
+		}
+		pairs = append(pairs, f.name, s)
+	}
+	return t.route.URL(pairs...)
+}
+
+// Params decodes the route variables of the current request into a value
+// of type P. See Vars().
+func (t *TypedRoute[P]) Params(r *http.Request) (P, error) {
+	var params P
+	if t.route.err != nil {
+		return params, t.route.err
+	}
+	vars := Vars(r)
+	v := reflect.ValueOf(&params).Elem()
+	for _, f := range t.fields {
+		s, ok := vars[f.name]
+		if !ok {
+			return params, fmt.Errorf("mux: missing route variable %q", f.name)
+		}
+		if err := parseTypedValue(v.FieldByIndex(f.index), s); err != nil {
+			return params, fmt.Errorf("mux: cannot parse route variable %q: %w", f.name, err)
+		}
+	}
+	return params, nil
+}
+
+// typedFields maps the fields of the struct type typ to the given variable
+// names. Every variable must be mapped to exactly one field, and vice versa.
+func typedFields(typ reflect.Type, varNames []string) ([]typedField, error) {
+	if typ.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("mux: route parameters must be a struct, got %v", typ)
+	}
+	fields := make([]typedField, 0, len(varNames))
+	mapped := make(map[string]string, len(varNames))
+	for _, sf := range reflect.VisibleFields(typ) {
+		if !sf.IsExported() || sf.Anonymous {
+			continue
+		}
+		tag := sf.Tag.Get("mux")
+		if tag == "-" {
+			continue
+		}
+		name := tag
+		if name == "" {
+			for _, v := range varNames {
+				if strings.EqualFold(v, sf.Name) {
+					name = v
+					break
+				}
+			}
+		}
+		if !matchInArray(varNames, name) {
+			return nil, fmt.Errorf("mux: field %s of %v doesn't match any route variable", sf.Name, typ)
+		}
+		if other, ok := mapped[name]; ok {
+			return nil, fmt.Errorf("mux: fields %s and %s of %v both match route variable %q", other, sf.Name, typ, name)
+		}
+		if !isTypedKind(sf.Type) {
+			return nil, fmt.Errorf("mux: field %s of %v has unsupported type %v", sf.Name, typ, sf.Type)
+		}
+		if throughPointer(typ, sf.Index) {
+			return nil, fmt.Errorf("mux: field %s of %v is promoted through an embedded pointer", sf.Name, typ)
+		}
+		mapped[name] = sf.Name
+		fields = append(fields, typedField{index: sf.Index, name: name})
+	}
+	for _, v := range varNames {
+		if _, ok := mapped[v]; !ok {
+			return nil, fmt.Errorf("mux: route variable %q doesn't match any field of %v", v, typ)
+		}
+	}
+	return fields, nil
+}
+
+// throughPointer reports whether the field of typ with the given index is
+// promoted through an embedded pointer, which may be nil.
+func throughPointer(typ reflect.Type, index []int) bool {
+	for i := 1; i < len(index); i++ {
+		if typ.FieldByIndex(index[:i]).Type.Kind() == reflect.Pointer {
+			return true
+		}
+	}
+	return false
+}
+
+var (
+	textMarshalerType   = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+	textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+)
+
+// isTypedKind reports whether a field of type typ can be used as a route
+// variable.
+func isTypedKind(typ reflect.Type) bool {
+	if typ.Implements(textMarshalerType) && reflect.PointerTo(typ).Implements(textUnmarshalerType) {
+		return true
+	}
+	switch typ.Kind() {
+	case reflect.String, reflect.Bool,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return true
+	}
+	return false
+}
+
+func formatTypedValue(v reflect.Value) (string, error) {
+	if m, ok := v.Interface().(encoding.TextMarshaler); ok {
+		b, err := m.MarshalText()
+		return string(b), err
+	}
+	switch v.Kind() {
+	case reflect.String:
+		return v.String(), nil
+	case reflect.Bool:
+		return strconv.FormatBool(v.Bool()), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(v.Int(), 10), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return strconv.FormatUint(v.Uint(), 10), nil
+	case reflect.Float32, reflect.Float64:
+		return strconv.FormatFloat(v.Float(), 'g', -1, v.Type().Bits()), nil
+	}
+	return "", fmt.Errorf("unsupported type %v", v.Type())
+}
+
+func parseTypedValue(v reflect.Value, s string) error {
+	if u, ok := v.Addr().Interface().(encoding.TextUnmarshaler); ok {
+		return u.UnmarshalText([]byte(s))
+	}
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(s)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			return err
+		}
+		v.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(s, 10, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(s, 10, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(s, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetFloat(f)
+	default:
+		return fmt.Errorf("unsupported type %v", v.Type())
+	}
+	return nil
+}
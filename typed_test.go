@@ -0,0 +1,139 @@
+package mux
+
+import (
+	"net/http"
+	"testing"
+	"time"
+)
+
+type articleParams struct {
+	Category string `mux:"category"`
+	ID       int
+	Ignored  string `mux:"-"`
+}
+
+func TestTypedRoute(t *testing.T) {
+	r := NewRouter()
+	var got articleParams
+	var article *TypedRoute[articleParams]
+	article = Register[articleParams](r, "/articles/{category}/{id:[0-9]+}", http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		var err error
+		got, err = article.Params(req)
+		if err != nil {
+			t.Errorf("Unexpected error: %v", err)
+		}
+	}))
+	if err := article.Route().GetError(); err != nil {
+		t.Fatalf("Unexpected route error: %v", err)
+	}
+
+	u, err := article.URL(articleParams{Category: "tech", ID: 42})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if expected := "/articles/tech/42"; u.String() != expected {
+		t.Errorf("Expected %q, got %q", expected, u.String())
+	}
+
+	r.ServeHTTP(NewRecorder(), newRequest("GET", "http://localhost/articles/tech/42"))
+	if expected := (articleParams{Category: "tech", ID: 42}); got != expected {
+		t.Errorf("Expected params %+v, got %+v", expected, got)
+	}
+
+	if _, err := article.URL(articleParams{Category: "tech", ID: -1}); err == nil {
+		t.Error("Expected an error for a value not matching the variable pattern")
+	}
+}
+
+func TestTypedRouteTextUnmarshaler(t *testing.T) {
+	type params struct {
+		Day time.Time `mux:"day"`
+	}
+	r := NewRouter()
+	route := Register[params](r, "/days/{day}", nil)
+
+	day := time.Date(2024, 2, 29, 0, 0, 0, 0, time.UTC)
+	u, err := route.URL(params{Day: day})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if expected := "/days/2024-02-29T00:00:00Z"; u.String() != expected {
+		t.Errorf("Expected %q, got %q", expected, u.String())
+	}
+
+	req := SetURLVars(newRequest("GET", "http://localhost"+u.String()), map[string]string{"day": "2024-02-29T00:00:00Z"})
+	p, err := route.Params(req)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if !p.Day.Equal(day) {
+		t.Errorf("Expected %v, got %v", day, p.Day)
+	}
+
+	req = SetURLVars(req, map[string]string{"day": "yesterday"})
+	if _, err := route.Params(req); err == nil {
+		t.Error("Expected an error for an invalid value")
+	}
+}
+
+func TestTypedRouteMismatch(t *testing.T) {
+	tests := []struct {
+		title string
+		err   error
+	}{
+		{"missing field", Register[struct {
+			Category string
+		}](NewRouter(), "/articles/{category}/{id}", nil).Route().GetError()},
+		{"extra field", Register[struct {
+			Category string
+			Page     int
+		}](NewRouter(), "/articles/{category}", nil).Route().GetError()},
+		{"unsupported type", Register[struct {
+			Category []string
+		}](NewRouter(), "/articles/{category}", nil).Route().GetError()},
+		{"not a struct", Register[string](NewRouter(), "/articles/{category}", nil).Route().GetError()},
+		{"embedded pointer", Register[struct {
+			*TypedInner
+			Category string
+		}](NewRouter(), "/articles/{category}/{id}", nil).Route().GetError()},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.title, func(t *testing.T) {
+			if tc.err == nil {
+				t.Error("Expected a route error")
+			}
+		})
+	}
+}
+
+type TypedInner struct {
+	ID int
+}
+
+func TestTypedRouteEmbedded(t *testing.T) {
+	type params struct {
+		TypedInner
+		Category string
+	}
+	r := NewRouter()
+	route := Register[params](r, "/articles/{category}/{id}", nil)
+	if err := route.Route().GetError(); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	u, err := route.URL(params{TypedInner{42}, "tech"})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if u.Path != "/articles/tech/42" {
+		t.Errorf("Expected %q, got %q", "/articles/tech/42", u.Path)
+	}
+	req := SetURLVars(newRequest("GET", "/articles/tech/42"), map[string]string{"category": "tech", "id": "42"})
+	p, err := route.Params(req)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if p.ID != 42 || p.Category != "tech" {
+		t.Errorf("Expected %+v, got %+v", params{TypedInner{42}, "tech"}, p)
+	}
+}
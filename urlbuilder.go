@@ -0,0 +1,92 @@
+// Copyright 2012 The Gorilla Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package mux
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// URLBuilder builds a URL for a route, optionally with query parameters that
+// were not declared with Route.Queries and with a fragment.
+//
+// A URLBuilder is obtained from Route.URLBuilder:
+//
+//	u, err := r.Get("article").URLBuilder().
+//	  Vars("category", "technology", "id", "42").
+//	  Query(url.Values{"page": {"2"}}).
+//	  Fragment("comments").
+//	  Build()
+//
+// ...which will return an url.URL with the following string form:
+//
+//	"/articles/technology/42?page=2#comments"
+type URLBuilder struct {
+	route    *Route
+	pairs    []string
+	query    url.Values
+	fragment string
+}
+
+// URLBuilder returns a URLBuilder for the route.
+func (r *Route) URLBuilder() *URLBuilder {
+	return &URLBuilder{route: r}
+}
+
+// Vars appends a sequence of key/value pairs for the route variables.
+// See Route.URL().
+func (b *URLBuilder) Vars(pairs ...string) *URLBuilder {
+	b.pairs = append(b.pairs, pairs...)
+	return b
+}
+
+// Query adds query parameters which are appended after the ones declared
+// with Route.Queries. They are encoded sorted by key, and the values of a
+// given key keep their order.
+//
+// It is an error for a key to collide with a query parameter declared on
+// the route.
+func (b *URLBuilder) Query(values url.Values) *URLBuilder {
+	if b.query == nil {
+		b.query = make(url.Values, len(values))
+	}
+	for k, v := range values {
+		b.query[k] = append(b.query[k], v...)
+	}
+	return b
+}
+
+// Fragment sets the fragment of the URL, without the leading '#'.
+func (b *URLBuilder) Fragment(fragment string) *URLBuilder {
+	b.fragment = fragment
+	return b
+}
+
+// Build builds the URL. See Route.URL().
+func (b *URLBuilder) Build() (*url.URL, error) {
+	u, err := b.route.URL(b.pairs...)
+	if err != nil {
+		return nil, err
+	}
+	if len(b.query) > 0 {
+		for _, q := range b.route.regexp.queries {
+			key := strings.SplitN(q.template, "=", 2)[0]
+			if _, ok := b.query[key]; ok {
+				return nil, fmt.Errorf("mux: query parameter %q is already declared by the route", key)
+			}
+		}
+		switch extra := b.query.Encode(); {
+		case extra == "":
+			// Keys without values encode to nothing.
+		case u.RawQuery == "":
+			u.RawQuery = extra
+		default:
+			u.RawQuery += "&" + extra
+		}
+	}
+	u.Fragment = b.fragment
+	return u, nil
+}
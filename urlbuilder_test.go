@@ -0,0 +1,90 @@
+package mux
+
+import (
+	"net/url"
+	"testing"
+)
+
+func TestURLBuilder(t *testing.T) {
+	r := NewRouter()
+	r.HandleFunc("/articles/{category}/{id:[0-9]+}", nil).
+		Queries("sort", "{sort}").
+		Name("article")
+	route := r.Get("article")
+
+	tests := []struct {
+		title    string
+		builder  *URLBuilder
+		expected string
+		wantErr  bool
+	}{
+		{
+			title:    "declared variables only",
+			builder:  route.URLBuilder().Vars("category", "tech", "id", "42", "sort", "asc"),
+			expected: "/articles/tech/42?sort=asc",
+		},
+		{
+			title: "extra query parameters are sorted by key",
+			builder: route.URLBuilder().Vars("category", "tech", "id", "42", "sort", "asc").
+				Query(url.Values{"page": {"2"}, "a b": {"x&y", "z"}}),
+			expected: "/articles/tech/42?sort=asc&a+b=x%26y&a+b=z&page=2",
+		},
+		{
+			title: "extra query parameters without values",
+			builder: route.URLBuilder().Vars("category", "tech", "id", "42", "sort", "asc").
+				Query(url.Values{"a": {}}),
+			expected: "/articles/tech/42?sort=asc",
+		},
+		{
+			title: "fragment",
+			builder: route.URLBuilder().Vars("category", "tech", "id", "42", "sort", "asc").
+				Fragment("comments"),
+			expected: "/articles/tech/42?sort=asc#comments",
+		},
+		{
+			title: "collision with a declared query parameter",
+			builder: route.URLBuilder().Vars("category", "tech", "id", "42", "sort", "asc").
+				Query(url.Values{"sort": {"desc"}}),
+			wantErr: true,
+		},
+		{
+			title:   "invalid variable",
+			builder: route.URLBuilder().Vars("category", "tech", "id", "x", "sort", "asc"),
+			wantErr: true,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.title, func(t *testing.T) {
+			u, err := tc.builder.Build()
+			if tc.wantErr {
+				if err == nil {
+					t.Fatalf("Expected an error, got %q", u)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if got := u.String(); got != tc.expected {
+				t.Errorf("Expected %q, got %q", tc.expected, got)
+			}
+		})
+	}
+}
+
+func TestURLBuilderWithoutDeclaredQueries(t *testing.T) {
+	r := NewRouter()
+	route := r.Host("{sub}.example.com").Path("/search")
+
+	u, err := route.URLBuilder().
+		Vars("sub", "www").
+		Query(url.Values{"q": {"mux"}}).
+		Build()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if expected, got := "http://www.example.com/search?q=mux", u.String(); expected != got {
+		t.Errorf("Expected %q, got %q", expected, got)
+	}
+}